@@ -0,0 +1,154 @@
+package pflagx
+
+import (
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// argKind is the kind of a command-line argument found by scanArgs.
+type argKind int
+
+const (
+	// argPositional is a positional argument, including "-".
+	argPositional argKind = iota
+
+	// argTerminator is the "--" separator ending the flags.
+	argTerminator
+
+	// argLong is a long flag, e.g. "--name" or "--name=value".
+	argLong
+
+	// argShort is one or more combined shorthand flags, e.g. "-vp 8080".
+	argShort
+)
+
+// argToken is a command-line argument found by scanArgs, along with the
+// arguments consumed as the values of its flags.
+type argToken struct {
+	kind argKind
+
+	// index and end delimit the argument and the values consumed after it
+	// in the scanned arguments.
+	index, end int
+
+	// flags holds the long flag, or each of the combined shorthand flags.
+	flags []flagToken
+}
+
+// flagToken is a flag of an argToken.
+type flagToken struct {
+	// name is the long name or the shorthand letter given on the command line.
+	name string
+
+	// flag is the flag of the FlagSet, or nil if it is unknown.
+	flag *pflag.Flag
+
+	// help is set for the undefined help flags, "--help" and "-h".
+	help bool
+
+	// value is the value attached to the flag, e.g. "8080" for "-p8080" or
+	// "--port=8080", if hasValue is set.
+	value    string
+	hasValue bool
+
+	// valueIndex is the index of the argument consumed as the value, or -1.
+	valueIndex int
+}
+
+// scanArgs splits args the way pflag parses them with fs and calls fn for
+// each argument until it returns false or the arguments are exhausted. Known
+// flags consume the next argument as their value when they need one, and
+// unknown flags consume it if it does not look like a flag when fs ignores
+// unknown flags, as pflag does. The undefined help flags take no value.
+// Long names are resolved with lookup, or fs.Lookup if it is nil.
+func scanArgs(fs *pflag.FlagSet, args []string, lookup func(name string) *pflag.Flag, fn func(t argToken) bool) {
+	if lookup == nil {
+		lookup = fs.Lookup
+	}
+	skipUnknown := fs.ParseErrorsWhitelist.UnknownFlags
+
+	for i := 0; i < len(args); {
+		s := args[i]
+		t := argToken{index: i}
+		next := i + 1
+
+		// consume returns the index of the next argument, consumed as the
+		// value of a flag, or -1 if there is none or it looks like a flag
+		// while the flag is unknown
+		consume := func(known bool) int {
+			if next >= len(args) {
+				return -1
+			}
+			if !known && (!skipUnknown || strings.HasPrefix(args[next], "-")) {
+				return -1
+			}
+			next++
+			return next - 1
+		}
+
+		switch {
+		case len(s) < 2 || s[0] != '-':
+			t.kind = argPositional
+
+		case s == "--":
+			t.kind = argTerminator
+
+		case s[1] == '-':
+			t.kind = argLong
+			name, value, hasValue := strings.Cut(s[2:], "=")
+			f := flagToken{name: name, flag: lookup(name), value: value, hasValue: hasValue, valueIndex: -1}
+			f.help = f.flag == nil && name == "help"
+
+			switch {
+			case hasValue || f.help:
+			case f.flag == nil:
+				f.valueIndex = consume(false)
+			case f.flag.NoOptDefVal == "":
+				f.valueIndex = consume(true)
+			}
+			t.flags = []flagToken{f}
+
+		default:
+			t.kind = argShort
+			shorthands := s[1:]
+			for len(shorthands) > 0 {
+				f := flagToken{name: shorthands[:1], flag: fs.ShorthandLookup(shorthands[:1]), valueIndex: -1}
+				f.help = f.flag == nil && f.name == "h"
+				rest := shorthands[1:]
+
+				switch {
+				case f.help:
+				case len(shorthands) > 2 && shorthands[1] == '=':
+					f.value, f.hasValue, rest = shorthands[2:], true, ""
+				case f.flag == nil:
+					f.valueIndex = consume(false)
+				case f.flag.NoOptDefVal != "":
+				case rest != "":
+					f.value, f.hasValue, rest = rest, true, ""
+				default:
+					f.valueIndex = consume(true)
+				}
+
+				t.flags = append(t.flags, f)
+				shorthands = rest
+			}
+		}
+
+		t.end = next
+		if !fn(t) {
+			return
+		}
+		i = next
+	}
+}
+
+// known reports whether every flag of the token is defined or is a help flag.
+func (t argToken) known() bool {
+	for _, f := range t.flags {
+		if f.flag == nil && !f.help {
+			return false
+		}
+	}
+	return true
+}
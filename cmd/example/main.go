@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -27,6 +28,7 @@ filter        Optional pattern to filter files (e.g., "*.txt")`
 	generalFlags.Description = "This is a description for the General Options group."
 	verbose := generalFlags.BoolP("verbose", "v", false, "Enable verbose output")
 	config := generalFlags.StringP("config", "c", "", "Path to configuration file")
+	generalFlags.MarkFileCompletion("config", "yaml", "yml")
 	generalFlags.Bool("dry-run", false, "Perform a trial run with no changes made.\nThis flag's usage appear on multiple lines\nin order to show what the indentation looks like")
 
 	databaseFlags := cmd.NewFlagSet("Database Options")
@@ -56,6 +58,7 @@ filter        Optional pattern to filter files (e.g., "*.txt")`
 	debugFlags.Lookup("trace").Hidden = true
 
 	exampleFlags := cmd.NewFlagSet("Examples")
+	exampleFlags.FooterIsCode = true
 	exampleFlags.Footer = `# Basic usage with source and destination
 myapp /path/to/source /path/to/dest
 
@@ -74,26 +77,34 @@ myapp --dry-run --timeout 30s --retry 5 --factor 2.0 /source /dest
 # Using a configuration file
 myapp -c /etc/myapp/config.yaml /source /dest "*.dat"`
 
+	// Define the positional arguments
+	cmd.AddPositional("source", true)
+	cmd.AddPositional("destination", true)
+	cmd.AddPositional("filter", false)
+
+	// Add a hidden "completion <shell>" subcommand
+	cmd.EnableCompletionCommand()
+	cmd.EnableStructuredHelp()
+
 	// Parse command line arguments
 	if err := cmd.Parse(); err != nil {
+		if errors.Is(err, pflagx.ErrHelp) || errors.Is(err, pflagx.ErrVersion) ||
+			errors.Is(err, pflagx.ErrCompletion) {
+			os.Exit(0)
+		}
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
 	// Validate number of arguments
-	if cmd.NArg() < 2 || cmd.NArg() > 3 {
-		fmt.Fprintf(os.Stderr, "Error: invalid number of arguments\n")
-		cmd.Usage()
-		os.Exit(1)
+	if err := cmd.ValidatePositionals(); err != nil {
+		cmd.FailUsage("%v", err)
 	}
 
 	// Get positional arguments
-	source := cmd.Arg(0)
-	destination := cmd.Arg(1)
-	filter := ""
-	if cmd.NArg() > 2 {
-		filter = cmd.Arg(2)
-	}
+	source := cmd.Positional("source")
+	destination := cmd.Positional("destination")
+	filter := cmd.Positional("filter")
 
 	// Print positional arguments
 	fmt.Printf("Source: %s\n", source)
@@ -0,0 +1,41 @@
+// Package cobrax bridges pflagx commands to spf13/cobra. It lives in its own
+// module so that pflagx itself does not depend on cobra.
+package cobrax
+
+import (
+	"io"
+
+	"github.com/d3mondev/pflagx"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// ToCobra returns a cobra.Command with the flags of every FlagSet of cmd.
+// The flags are shared with cmd, so their hidden, deprecated and shorthand
+// metadata are preserved and parsed values are visible from both commands.
+// Help and usage output are rendered by cmd, reproducing its grouped layout.
+func ToCobra(cmd *pflagx.Command) *cobra.Command {
+	c := &cobra.Command{
+		Use:     cmd.Name,
+		Version: cmd.Version,
+		Long:    cmd.Description,
+	}
+
+	cmd.WalkFlags(func(group string, f *pflag.Flag) bool {
+		if c.Flags().Lookup(f.Name) == nil {
+			c.Flags().AddFlag(f)
+		}
+		return true
+	})
+
+	c.SetUsageFunc(func(c *cobra.Command) error {
+		_, err := io.WriteString(c.OutOrStderr(), cmd.UsageString())
+		return err
+	})
+
+	c.SetHelpFunc(func(c *cobra.Command, args []string) {
+		io.WriteString(c.OutOrStdout(), cmd.UsageString())
+	})
+
+	return c
+}
@@ -0,0 +1,133 @@
+package cobrax
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/d3mondev/pflagx"
+	"github.com/spf13/cobra"
+)
+
+func TestToCobra(t *testing.T) {
+	cmd := pflagx.New()
+	cmd.Name = "myapp"
+	cmd.Version = "v1.0.0"
+	cmd.Description = "A test application."
+
+	general := cmd.NewFlagSet("General Options")
+	verbose := general.BoolP("verbose", "v", false, "Enable verbose output")
+	general.String("secret", "", "Hidden secret")
+	general.MarkHidden("secret")
+	general.String("old", "", "Old flag")
+	general.MarkDeprecated("old", "use --new instead")
+
+	database := cmd.NewFlagSet("Database Options")
+	host := database.String("db-host", "localhost", "Database server hostname")
+
+	c := ToCobra(cmd)
+
+	if c.Use != "myapp" || c.Version != "v1.0.0" || c.Long != "A test application." {
+		t.Errorf("unexpected command metadata: Use=%q Version=%q Long=%q", c.Use, c.Version, c.Long)
+	}
+
+	tests := []struct {
+		name       string
+		shorthand  string
+		hidden     bool
+		deprecated string
+	}{
+		{name: "verbose", shorthand: "v"},
+		{name: "secret", hidden: true},
+		{name: "old", hidden: true, deprecated: "use --new instead"},
+		{name: "db-host"},
+		{name: "version"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := c.Flags().Lookup(tt.name)
+			if f == nil {
+				t.Fatalf("flag --%s not found", tt.name)
+			}
+			if f.Shorthand != tt.shorthand {
+				t.Errorf("shorthand = %q, want %q", f.Shorthand, tt.shorthand)
+			}
+			if f.Hidden != tt.hidden {
+				t.Errorf("hidden = %v, want %v", f.Hidden, tt.hidden)
+			}
+			if f.Deprecated != tt.deprecated {
+				t.Errorf("deprecated = %q, want %q", f.Deprecated, tt.deprecated)
+			}
+		})
+	}
+
+	var out bytes.Buffer
+	c.SetOut(&out)
+	c.SetErr(&out)
+	c.SetArgs([]string{"-v", "--db-host", "db.example.com"})
+	c.Run = func(*cobra.Command, []string) {}
+	if err := c.Execute(); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if !*verbose || *host != "db.example.com" {
+		t.Errorf("values not shared with pflagx: verbose=%v db-host=%q", *verbose, *host)
+	}
+
+	out.Reset()
+	c.SetArgs([]string{"--help"})
+	if err := c.Execute(); err != nil {
+		t.Fatalf("Execute(--help) error = %v", err)
+	}
+
+	help := out.String()
+	for _, want := range []string{
+		"General Options:\n",
+		"  -v, --verbose",
+		"Database Options:\n",
+		`--db-host    Database server hostname (default: "localhost")`,
+	} {
+		if !strings.Contains(help, want) {
+			t.Errorf("help does not contain %q:\n%s", want, help)
+		}
+	}
+	if strings.Contains(help, "--secret") {
+		t.Errorf("help shows the hidden flag:\n%s", help)
+	}
+}
+
+func TestToCobraUsageOnError(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "unknown flag", args: []string{"--unknown"}},
+		{name: "invalid value", args: []string{"--db-port", "http"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := pflagx.New()
+			cmd.Name = "myapp"
+			cmd.NewFlagSet("General Options").BoolP("verbose", "v", false, "Enable verbose output")
+			cmd.NewFlagSet("Database Options").Int("db-port", 5432, "Database server port")
+
+			c := ToCobra(cmd)
+			c.Run = func(*cobra.Command, []string) {}
+			c.SilenceErrors = true
+
+			var out bytes.Buffer
+			c.SetOut(&out)
+			c.SetErr(&out)
+			c.SetArgs(tt.args)
+			if err := c.Execute(); err == nil {
+				t.Fatal("Execute() error = nil, want an error")
+			}
+
+			// Cobra prints the usage followed by a newline.
+			if want := cmd.UsageString() + "\n"; out.String() != want {
+				t.Errorf("usage on error =\n%s\nwant:\n%s", out.String(), want)
+			}
+		})
+	}
+}
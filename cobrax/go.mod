@@ -0,0 +1,17 @@
+module github.com/d3mondev/pflagx/cobrax
+
+go 1.24.0
+
+require (
+	github.com/d3mondev/pflagx v0.0.0-20261017014737-329fdbcefc85
+	github.com/spf13/cobra v1.9.1
+	github.com/spf13/pflag v1.0.6
+)
+
+require (
+	github.com/BurntSushi/toml v1.5.0 // indirect
+	github.com/inconshreveable/mousetrap v1.1.0 // indirect
+	golang.org/x/sys v0.37.0 // indirect
+	golang.org/x/term v0.36.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
@@ -0,0 +1,239 @@
+package pflagx
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ansiReset is the SGR sequence that resets all attributes.
+const ansiReset = "\x1b[0m"
+
+// Colors holds the ANSI SGR sequences, such as "\x1b[1m", used to color the
+// different parts of the help output. An empty sequence leaves a part
+// uncolored.
+type Colors struct {
+	// GroupTitle colors the Name of each FlagSet.
+	GroupTitle string
+
+	// FlagName colors the long flag names.
+	FlagName string
+
+	// Shorthand colors the shorthand flags.
+	Shorthand string
+
+	// Default colors the default value annotations.
+	Default string
+
+	// Description colors the Command and FlagSet descriptions.
+	Description string
+}
+
+// ColorMode determines when help output is colored.
+type ColorMode int
+
+const (
+	// ColorAuto colors output unless NO_COLOR is set, Writer is not a
+	// terminal, or a bool flag named "no-color" is set.
+	ColorAuto ColorMode = iota
+
+	// ColorAlways always colors output.
+	ColorAlways
+
+	// ColorNever never colors output.
+	ColorNever
+)
+
+// DefaultColors are the colors used by default when color is enabled.
+var DefaultColors = Colors{
+	GroupTitle: "\x1b[1m",
+	FlagName:   "\x1b[36m",
+	Shorthand:  "\x1b[36m",
+	Default:    "\x1b[2m",
+}
+
+// activeColors returns the Colors to use for help output, which are empty
+// unless EnableColor is set and colorEnabled reports true. The colors are
+// degraded to the level of color support of the output.
+func (cmd *Command) activeColors() Colors {
+	if !cmd.EnableColor || !colorEnabled(cmd) {
+		return Colors{}
+	}
+
+	level := cmd.colorLevel()
+	if level <= 0 {
+		return Colors{}
+	}
+
+	c := cmd.Colors
+	return Colors{
+		GroupTitle:  degradeSGR(c.GroupTitle, level),
+		FlagName:    degradeSGR(c.FlagName, level),
+		Shorthand:   degradeSGR(c.Shorthand, level),
+		Default:     degradeSGR(c.Default, level),
+		Description: degradeSGR(c.Description, level),
+	}
+}
+
+// colorLevel returns the ColorLevel of the Command if set, else the level
+// detected for Writer. ColorAlways uses at least the basic colors.
+func (cmd *Command) colorLevel() int {
+	if cmd.ColorLevel != nil {
+		return *cmd.ColorLevel
+	}
+
+	level := colorLevel(cmd.Writer)
+	if cmd.ColorMode == ColorAlways {
+		level = max(level, 1)
+	}
+	return level
+}
+
+// degradeSGR returns the SGR sequence with its 256 colors and truecolor
+// parameters replaced by the closest colors available at the level: 256
+// colors at level 2 and basic colors at level 1. Sequences it cannot parse
+// are returned unchanged.
+func degradeSGR(sgr string, level int) string {
+	if level >= 3 || !strings.HasPrefix(sgr, "\x1b[") || !strings.HasSuffix(sgr, "m") {
+		return sgr
+	}
+
+	params := strings.Split(sgr[2:len(sgr)-1], ";")
+	out := make([]string, 0, len(params))
+	for i := 0; i < len(params); i++ {
+		p := params[i]
+		if (p != "38" && p != "48") || i+1 >= len(params) {
+			out = append(out, p)
+			continue
+		}
+
+		var r, g, b, n int
+		switch {
+		case params[i+1] == "2" && i+4 < len(params):
+			r, g, b = atoiByte(params[i+2]), atoiByte(params[i+3]), atoiByte(params[i+4])
+			n = rgbTo256(r, g, b)
+			i += 4
+		case params[i+1] == "5" && i+2 < len(params):
+			n = atoiByte(params[i+2])
+			r, g, b = rgbOf256(n)
+			i += 2
+		default:
+			out = append(out, p)
+			continue
+		}
+
+		if level == 2 {
+			out = append(out, p, "5", strconv.Itoa(n))
+			continue
+		}
+
+		base := 30
+		if p == "48" {
+			base = 40
+		}
+		out = append(out, strconv.Itoa(base+basicOf(n, r, g, b)))
+	}
+
+	return "\x1b[" + strings.Join(out, ";") + "m"
+}
+
+// atoiByte parses s as a color component, clamped between 0 and 255.
+func atoiByte(s string) int {
+	n, _ := strconv.Atoi(s)
+	return min(max(n, 0), 255)
+}
+
+// cubeLevels are the component values of the 6x6x6 color cube of the 256
+// colors palette.
+var cubeLevels = [6]int{0, 95, 135, 175, 215, 255}
+
+// rgbTo256 returns the color of the 6x6x6 cube of the 256 colors palette
+// closest to r, g and b.
+func rgbTo256(r, g, b int) int {
+	return 16 + 36*cubeIndex(r) + 6*cubeIndex(g) + cubeIndex(b)
+}
+
+func cubeIndex(v int) int {
+	best := 0
+	for i, l := range cubeLevels {
+		if abs(v-l) < abs(v-cubeLevels[best]) {
+			best = i
+		}
+	}
+	return best
+}
+
+// rgbOf256 returns the components of the color n of the 256 colors palette.
+// The 16 basic colors are approximated.
+func rgbOf256(n int) (int, int, int) {
+	switch {
+	case n < 16:
+		v := 128
+		if n >= 8 {
+			v = 255
+		}
+		return v * (n & 1), v * (n >> 1 & 1), v * (n >> 2 & 1)
+	case n < 232:
+		n -= 16
+		return cubeLevels[n/36], cubeLevels[n/6%6], cubeLevels[n%6]
+	default:
+		v := 8 + (n-232)*10
+		return v, v, v
+	}
+}
+
+// basicOf returns the offset of the basic color, from 0 (black) to 7 (white),
+// closest to the color n of the 256 colors palette with components r, g and b.
+func basicOf(n, r, g, b int) int {
+	if n < 16 {
+		return n % 8
+	}
+
+	c := 0
+	if r >= 128 {
+		c |= 1
+	}
+	if g >= 128 {
+		c |= 2
+	}
+	if b >= 128 {
+		c |= 4
+	}
+	return c
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// colorEnabled reports whether decorative output is enabled according to the
+// ColorMode of the Command. In ColorAuto mode, it is disabled if NO_COLOR is
+// set, if Writer is not a terminal, or if a bool flag named "no-color" is set.
+func colorEnabled(cmd *Command) bool {
+	switch cmd.ColorMode {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	if f := cmd.lookupFlag("no-color"); f != nil && f.Value.Type() == "bool" && f.Value.String() == "true" {
+		return false
+	}
+	return isTerminal(cmd.Writer)
+}
+
+// paint wraps s in the SGR sequence and a reset, or returns s unchanged
+// if sgr or s is empty.
+func paint(s, sgr string) string {
+	if sgr == "" || s == "" {
+		return s
+	}
+	return sgr + s + ansiReset
+}
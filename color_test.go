@@ -0,0 +1,183 @@
+package pflagx
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEnvColorLevel(t *testing.T) {
+	tests := []struct {
+		term      string
+		colorTerm string
+		want      int
+	}{
+		{term: "", want: 0},
+		{term: "dumb", colorTerm: "truecolor", want: 0},
+		{term: "xterm", want: 1},
+		{term: "xterm-256color", want: 2},
+		{term: "xterm-256color", colorTerm: "truecolor", want: 3},
+		{term: "xterm", colorTerm: "24bit", want: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.term+"/"+tt.colorTerm, func(t *testing.T) {
+			t.Setenv("TERM", tt.term)
+			t.Setenv("COLORTERM", tt.colorTerm)
+
+			if got := envColorLevel(); got != tt.want {
+				t.Errorf("envColorLevel() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestColorLevelNotTerminal(t *testing.T) {
+	t.Setenv("TERM", "xterm-256color")
+	t.Setenv("COLORTERM", "truecolor")
+
+	if got := colorLevel(&bytes.Buffer{}); got != 0 {
+		t.Errorf("colorLevel() = %d, want 0", got)
+	}
+}
+
+func TestDegradeSGR(t *testing.T) {
+	tests := []struct {
+		name  string
+		sgr   string
+		level int
+		want  string
+	}{
+		{name: "truecolor kept", sgr: "\x1b[38;2;255;0;0m", level: 3, want: "\x1b[38;2;255;0;0m"},
+		{name: "truecolor to 256", sgr: "\x1b[38;2;255;0;0m", level: 2, want: "\x1b[38;5;196m"},
+		{name: "truecolor to basic", sgr: "\x1b[38;2;255;0;0m", level: 1, want: "\x1b[31m"},
+		{name: "256 to basic", sgr: "\x1b[1;38;5;21m", level: 1, want: "\x1b[1;34m"},
+		{name: "background to basic", sgr: "\x1b[48;5;46m", level: 1, want: "\x1b[42m"},
+		{name: "basic kept", sgr: "\x1b[36m", level: 1, want: "\x1b[36m"},
+		{name: "unparsable kept", sgr: "bold", level: 1, want: "bold"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := degradeSGR(tt.sgr, tt.level); got != tt.want {
+				t.Errorf("degradeSGR() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestColorLevelOverride(t *testing.T) {
+	tests := []struct {
+		name  string
+		level int
+		want  string
+	}{
+		{name: "none", level: 0, want: "  -v, --verbose    Verbose output\n"},
+		{name: "basic", level: 1, want: "  \x1b[31m-v\x1b[0m, \x1b[31m--verbose\x1b[0m    Verbose output\n"},
+		{name: "256 colors", level: 2, want: "  \x1b[38;5;196m-v\x1b[0m, \x1b[38;5;196m--verbose\x1b[0m    Verbose output\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := newTestCommand(&out)
+			cmd.EnableColor = true
+			cmd.ColorMode = ColorAlways
+			cmd.ColorLevel = &tt.level
+			cmd.Colors = Colors{FlagName: "\x1b[38;2;255;0;0m", Shorthand: "\x1b[38;2;255;0;0m"}
+			cmd.NewFlagSet("").BoolP("verbose", "v", false, "Verbose output")
+
+			if got := cmd.UsageString(); got != "app\n"+tt.want {
+				t.Errorf("UsageString() = %q, want %q", got, "app\n"+tt.want)
+			}
+		})
+	}
+}
+
+func TestColoredHelp(t *testing.T) {
+	plain := "app\n" +
+		"General:\n" +
+		"  -o, --output    Output file (default: \"out\")\n"
+
+	tests := []struct {
+		name    string
+		enable  bool
+		mode    ColorMode
+		noColor bool
+		want    string
+	}{
+		{name: "disabled", mode: ColorAlways, want: plain},
+		{
+			name:   "enabled",
+			enable: true,
+			mode:   ColorAlways,
+			want: "app\n" +
+				"\x1b[1mGeneral:\x1b[0m\n" +
+				"  \x1b[36m-o\x1b[0m, \x1b[36m--output\x1b[0m    Output file \x1b[2m(default: \"out\")\x1b[0m\n",
+		},
+		{name: "not a terminal", enable: true, mode: ColorAuto, want: plain},
+		{name: "NO_COLOR", enable: true, mode: ColorAuto, noColor: true, want: plain},
+		{name: "never", enable: true, mode: ColorNever, want: plain},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.noColor {
+				t.Setenv("NO_COLOR", "1")
+			}
+
+			var out bytes.Buffer
+			cmd := newTestCommand(&out)
+			cmd.EnableColor = tt.enable
+			cmd.ColorMode = tt.mode
+			cmd.NewFlagSet("General").StringP("output", "o", "out", "Output file")
+
+			if got := cmd.UsageString(); got != tt.want {
+				t.Errorf("UsageString() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestColorEnabled(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     ColorMode
+		terminal bool
+		noColor  bool
+		args     []string
+		want     bool
+	}{
+		{name: "always", mode: ColorAlways, want: true},
+		{name: "always with NO_COLOR", mode: ColorAlways, noColor: true, want: true},
+		{name: "always with --no-color", mode: ColorAlways, args: []string{"--no-color"}, want: true},
+		{name: "never", mode: ColorNever, terminal: true},
+		{name: "auto", mode: ColorAuto, terminal: true, want: true},
+		{name: "auto not a terminal", mode: ColorAuto},
+		{name: "auto with NO_COLOR", mode: ColorAuto, terminal: true, noColor: true},
+		{name: "auto with --no-color", mode: ColorAuto, terminal: true, args: []string{"--no-color"}},
+		{name: "auto with --no-color=false", mode: ColorAuto, terminal: true, args: []string{"--no-color=false"}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.noColor {
+				t.Setenv("NO_COLOR", "")
+			}
+
+			var out bytes.Buffer
+			cmd := newTestCommand(&out)
+			if tt.terminal {
+				cmd.Writer = openTerminal(t)
+			}
+			cmd.ColorMode = tt.mode
+			cmd.NewFlagSet("General").Bool("no-color", false, "Disable colors")
+			if err := cmd.ParseSilent(tt.args); err != nil {
+				t.Fatalf("ParseSilent() error = %v", err)
+			}
+
+			if got := colorEnabled(cmd); got != tt.want {
+				t.Errorf("colorEnabled() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
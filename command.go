@@ -4,8 +4,14 @@ package pflagx
 import (
 	"bufio"
 	"errors"
+	"fmt"
 	"io"
+	"maps"
 	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"text/template"
 
 	"github.com/spf13/pflag"
 )
@@ -27,6 +33,36 @@ const (
 	// alignment is calculated per FlagSet (true) or globally (false)
 	// by default.
 	DefaultAlignUsagePerFlagSet = false
+
+	// DefaultWidth specifies the number of columns at which help output
+	// is wrapped when the width of the terminal cannot be detected.
+	DefaultWidth = 80
+
+	// DefaultQuoteStringDefaults determines whether the default values
+	// of string flags are quoted by default in help output.
+	DefaultQuoteStringDefaults = true
+
+	// DefaultRequiredSuffix is the default marker appended to the usage
+	// text of required flags in help output.
+	DefaultRequiredSuffix = " (required)"
+
+	// DefaultEnvSliceSeparator is the default separator on which the
+	// environment variables of slice flags are split.
+	DefaultEnvSliceSeparator = ","
+
+	// DefaultInterspersedArgs determines whether flags can follow
+	// positional arguments by default.
+	DefaultInterspersedArgs = true
+
+	// versionFlag is the name of the flag added to print the version.
+	versionFlag = "version"
+
+	// DefaultUsageExitCode is the default exit code of FailUsage.
+	DefaultUsageExitCode = 2
+
+	// DefaultGroupSeparator is the default text written between
+	// FlagSets in help output, leaving a blank line between them.
+	DefaultGroupSeparator = "\n"
 )
 
 // Command manages multiple FlagSets and provides unified parsing and help output.
@@ -40,6 +76,10 @@ type Command struct {
 	// Description appears at the top of help output.
 	Description string
 
+	// Hidden determines if a subcommand is omitted from the Commands section
+	// of the help output of its parent. It can still be used.
+	Hidden bool
+
 	// AlignUsagePerFlagSet determines if usage text alignment is calculated
 	// per FlagSet  (true) or globally across all FlagSets (false).
 	AlignUsagePerFlagSet bool
@@ -50,14 +90,259 @@ type Command struct {
 	// Padding is the minimum number of spaces between flag names and usage text.
 	Padding int
 
+	// ContinuationIndent is the number of spaces, possibly negative, added to
+	// the indentation of usage text continuation lines relative to the
+	// usage column.
+	ContinuationIndent int
+
 	// SortFlags determines if flags should be sorted alphabetically.
 	SortFlags bool
 
+	// Width is the number of columns at which usage text is wrapped.
+	// If zero, the COLUMNS environment variable is used if set, else the
+	// width of the terminal behind Writer, or DefaultWidth if Writer is not
+	// a terminal.
+	Width int
+
+	// QuoteStringDefaults determines if the default values of string flags
+	// are wrapped in quotes. Defaults containing whitespace or special
+	// characters are always quoted.
+	QuoteStringDefaults bool
+
+	// EnvPrefix, when not empty, binds every flag not explicitly bound with
+	// FlagSet.BindEnv or excluded with FlagSet.ExcludeEnv to the environment
+	// variable named after the prefix and the flag, e.g. MYAPP_DB_HOST for
+	// --db-host with the prefix "MYAPP". Subcommands without an EnvPrefix use
+	// the one of their parent. See AutoEnv.
+	EnvPrefix string
+
+	// EnvSliceSeparator is the separator on which the environment variables
+	// of slice flags are split into elements.
+	EnvSliceSeparator string
+
+	// ShowEnvInUsage determines if the environment variable bound to
+	// a flag is shown after its usage text, e.g. "[env: MYAPP_DB_HOST]".
+	ShowEnvInUsage bool
+
+	// RequiredSuffix is appended to the usage text of required flags,
+	// before the default value. An empty suffix disables the marker.
+	RequiredSuffix string
+
+	// ShowExamples determines if the example values set with
+	// FlagSet.SetExample are shown after the usage text.
+	ShowExamples bool
+
+	// DefaultLeader, when not empty, right-aligns the default values and
+	// fills the gap between the usage text and the default with it,
+	// e.g. "." for dotted leaders.
+	DefaultLeader string
+
+	// ShowTypes determines if the type of each flag is shown in its own
+	// column between the flag name and the usage text.
+	ShowTypes bool
+
+	// ShowBoolTypes determines if the "bool" type is shown when ShowTypes
+	// is set. Boolean flags conventionally show no type.
+	ShowBoolTypes bool
+
+	// HideDefaults determines if the default values of the flags are
+	// omitted from help output.
+	HideDefaults bool
+
+	// DefaultFormatter, when not nil, returns the default value annotation
+	// of a flag shown after its usage text, such as "[default: 8080]", or an
+	// empty string to omit it. When nil, defaults are shown as
+	// "(default: value)" when they are not zero values.
+	DefaultFormatter func(f *pflag.Flag) string
+
+	// GroupSeparator is written between the FlagSets shown in help output,
+	// after the trailing newline of each one. It is not written before the
+	// first FlagSet or after the last.
+	GroupSeparator string
+
+	// ShowDisabledFlagSets determines if FlagSets that are not Enabled
+	// are still shown in help output.
+	ShowDisabledFlagSets bool
+
+	// ShowSynopsis determines if the usage line returned by Synopsis is
+	// shown after the Description in help output. The line is wrapped to
+	// Width with continuation lines aligned under the first token following
+	// the program name.
+	ShowSynopsis bool
+
+	// DocAssignmentStyle determines if value flags are shown as
+	// "--config string" or "--config=string" in the Markdown and man page
+	// output. It does not apply to help output.
+	DocAssignmentStyle DocAssignmentStyle
+
+	// ShowGroupIndex determines if a line listing the FlagSets and their
+	// number of flags is shown at the top of help output.
+	ShowGroupIndex bool
+
+	// ShowRequiredSummary determines if a line listing the visible required
+	// flags of every FlagSet, e.g. "Required: --db-host, --db-user", is shown
+	// at the top of help output.
+	ShowRequiredSummary bool
+
+	// UsageFunc, when not nil, is called instead of Usage when help is
+	// requested or parsing fails. It can call Usage or UsageString to use
+	// the default rendering.
+	UsageFunc func(*Command)
+
+	// DisableVersionFlag determines if the --version flag, which prints the
+	// version and makes parsing return ErrVersion, is not added when Version
+	// is set. The flag is shown after the flags of the first FlagSet with
+	// visible flags, without being added to it, and is available to the
+	// subcommands. It is not added while a "version" flag is defined.
+	DisableVersionFlag bool
+
+	// UsageExitCode is the exit code used by FailUsage.
+	UsageExitCode int
+
+	// Template, when not nil, replaces the default layout of help output. It
+	// is executed with a TemplateData, and can use the TemplateFuncs. The
+	// default layout is used if the execution fails, and for filtered help.
+	Template *template.Template
+
+	// ReverseGroups determines if FlagSets are shown in reverse order of
+	// creation.
+	ReverseGroups bool
+
 	// Writer specifies where to write help output.
 	Writer io.Writer
 
+	// EnableColor determines if help output is colored using Colors, as
+	// decided by ColorMode.
+	EnableColor bool
+
+	// ColorMode determines when color is used if EnableColor is set. In the
+	// default ColorAuto mode, color is never used when Writer is not a
+	// terminal, NO_COLOR is set, or a bool flag named "no-color" is set.
+	ColorMode ColorMode
+
+	// Colors holds the colors used when EnableColor is set.
+	Colors Colors
+
+	// ColorLevel overrides the color support detected from TERM and
+	// COLORTERM when not nil: 0 for no color, 1 for the 16 basic colors,
+	// 2 for 256 colors and 3 for truecolor. Colors using more colors than
+	// supported are replaced by the closest ones available.
+	ColorLevel *int
+
+	// Pager is the command, such as "less -R", through which help output is
+	// piped when Writer is a terminal. Help is written directly to Writer
+	// if Pager is empty or cannot be started.
+	Pager string
+
+	// StrictConfig determines if LoadConfig fails without setting any flag
+	// when the config contains unknown keys.
+	StrictConfig bool
+
+	// ConfigKeyFunc, when not nil, returns the config key read by LoadConfig
+	// for the flag with the given long name, e.g. "db_host" for "db-host".
+	// When nil, the keys are the long names of the flags.
+	ConfigKeyFunc func(flagName string) string
+
+	// InterspersedArgs determines if flags can follow positional arguments.
+	// If false, the first positional argument ends the flags, and every
+	// argument after it is positional.
+	InterspersedArgs bool
+
+	// ResponseFiles determines if an argument of the form "@file" is replaced
+	// by the arguments read from file before parsing. The arguments in the
+	// file are separated by whitespace and can be quoted as in a shell. A
+	// leading "@@" stands for a literal "@". The setting of the Command that
+	// Parse is called on applies to the arguments of its subcommands too.
+	ResponseFiles bool
+
+	// AllowPrefixMatch determines if a long flag can be abbreviated to any
+	// prefix of its name that does not match another flag, such as --verb
+	// for --verbose. Exact matches always win over prefixes. Prefixes are
+	// matched against the flags of the subcommand that parses them, including
+	// the ones it inherits.
+	AllowPrefixMatch bool
+
+	// IgnoreUnknownFlags determines if unknown flags are collected instead of
+	// causing Parse to fail. The collected flags are available via
+	// UnknownFlags.
+	IgnoreUnknownFlags bool
+
+	// PassUnknownFlags determines if the unknown flags ignored because of
+	// IgnoreUnknownFlags are kept in Args, along with the values that follow
+	// them, in their original position among the positional arguments. The
+	// "--" separator is kept too, so that the arguments after it are not
+	// taken for flags. This allows forwarding them to another program.
+	PassUnknownFlags bool
+
+	// UnknownCommandFunc, when not nil, is called with the first positional
+	// argument when it does not name a subcommand, for a Command that has
+	// subcommands but no positional arguments. Its error is returned by
+	// Parse; if it returns nil, the argument is kept as a positional argument.
+	// When nil, an error suggesting the closest subcommand is returned.
+	UnknownCommandFunc func(name string) error
+
 	// flagSets holds all flag groups in order of creation.
 	flagSets []*FlagSet
+
+	// exclusiveGroups holds the names of the flags that are mutually exclusive.
+	exclusiveGroups [][]string
+
+	// togetherGroups holds the names of the flags that must be set together.
+	togetherGroups [][]string
+
+	// commands holds the subcommands in order of addition.
+	commands []*Command
+
+	// parent is the Command the Command was added to as a subcommand.
+	parent *Command
+
+	// structuredHelp determines if the help flag accepts the format of the
+	// help output as value. See EnableStructuredHelp.
+	structuredHelp bool
+
+	// action, when not nil, is called once the arguments of the Command are
+	// parsed, and its error is returned. The environment is not applied and
+	// the constraints on the flags, such as required flags, are not checked,
+	// so that an action like the completion subcommand works regardless of
+	// the flags of its parents. It must not write anything if silent is set.
+	action func(silent bool) error
+
+	// subcommand is the subcommand selected during the last parse.
+	subcommand *Command
+
+	// positionals holds the positional arguments in order.
+	positionals []*positional
+
+	// topics holds the help topics in order of creation.
+	topics []*topic
+
+	// buildInfo holds the build information shown by PrintVersion.
+	buildInfo map[string]string
+
+	// envFlags holds the names of the flags set from the environment
+	// during the last parse.
+	envFlags map[string]bool
+
+	// configFlags holds the names of the flags set from a config file.
+	configFlags map[string]bool
+
+	// flags holds the flags of the enabled FlagSets merged for the last
+	// parse, so that the global pflag.CommandLine is left alone.
+	flags *pflag.FlagSet
+
+	// versionFlag is the --version flag of the Command, created the first
+	// time it is needed. It belongs to no FlagSet of the user.
+	versionFlag *pflag.Flag
+
+	// versionGroup is the unnamed FlagSet showing versionFlag when no
+	// FlagSet has visible flags.
+	versionGroup *FlagSet
+
+	// args holds the positional arguments of the last parse.
+	args []string
+
+	// unknownFlags holds the unknown flags collected during the last Parse.
+	unknownFlags []string
 }
 
 // New creates a new Command with default settings.
@@ -67,6 +352,14 @@ func New() *Command {
 		Indentation:          DefaultIndentation,
 		Padding:              DefaultPadding,
 		SortFlags:            DefaultSortFlags,
+		QuoteStringDefaults:  DefaultQuoteStringDefaults,
+		RequiredSuffix:       DefaultRequiredSuffix,
+		EnvSliceSeparator:    DefaultEnvSliceSeparator,
+		GroupSeparator:       DefaultGroupSeparator,
+		InterspersedArgs:     DefaultInterspersedArgs,
+		UsageExitCode:        DefaultUsageExitCode,
+
+		Colors: DefaultColors,
 
 		Writer: os.Stderr,
 
@@ -78,60 +371,695 @@ func New() *Command {
 
 // NewFlagSet creates a new FlagSet group with the given name and adds it to the Command.
 func (cmd *Command) NewFlagSet(name string) *FlagSet {
-	fs := &FlagSet{
+	fs := cmd.newFlagSet(name)
+	cmd.flagSets = append(cmd.flagSets, fs)
+
+	return fs
+}
+
+// newFlagSet returns a FlagSet configured with the Command settings, without
+// adding it to the Command.
+func (cmd *Command) newFlagSet(name string) *FlagSet {
+	return &FlagSet{
 		FlagSet: pflag.NewFlagSet(name, pflag.ContinueOnError),
 
 		Name: name,
 
-		Indentation: cmd.Indentation,
-		Padding:     cmd.Padding,
-		SortFlags:   cmd.SortFlags,
+		Indentation:        cmd.Indentation,
+		Padding:            cmd.Padding,
+		ContinuationIndent: cmd.ContinuationIndent,
+		SortFlags:          cmd.SortFlags,
+
+		QuoteStringDefaults: cmd.QuoteStringDefaults,
+		RequiredSuffix:      cmd.RequiredSuffix,
+		ShowEnvInUsage:      cmd.ShowEnvInUsage,
+		ShowExamples:        cmd.ShowExamples,
+		DefaultLeader:       cmd.DefaultLeader,
+		ShowTypes:           cmd.ShowTypes,
+		ShowBoolTypes:       cmd.ShowBoolTypes,
+		HideDefaults:        cmd.HideDefaults,
+		DefaultFormatter:    cmd.DefaultFormatter,
+
+		Enabled: true,
+
+		cmd: cmd,
+	}
+}
+
+// AddFlagSet adds a FlagSet built outside the Command, such as one created
+// with NewFlagSet in another package or as a struct literal. Every setting
+// that NewFlagSet copies from the Command, such as Indentation or
+// HideDefaults, is replaced by the Command setting if it is left at its zero
+// value. The FlagSet is enabled when added; disable it afterwards to exclude
+// it from parsing. It returns an error if a FlagSet with the same name or
+// alias was already added, or if one of its flags has the same name or
+// shorthand as a flag of the Command or its parents.
+func (cmd *Command) AddFlagSet(fs *FlagSet) error {
+	for _, name := range append([]string{fs.Name}, fs.aliases...) {
+		if name == "" {
+			continue
+		}
+		if cmd.lookupFlagSet(name) != nil {
+			return fmt.Errorf("flag set %q already exists", name)
+		}
 	}
 
+	if fs.FlagSet == nil {
+		fs.FlagSet = pflag.NewFlagSet(fs.Name, pflag.ContinueOnError)
+	}
+
+	if err := cmd.checkFlagConflicts(fs); err != nil {
+		return err
+	}
+
+	if fs.Indentation == 0 {
+		fs.Indentation = cmd.Indentation
+	}
+	if fs.Padding == 0 {
+		fs.Padding = cmd.Padding
+	}
+	if fs.ContinuationIndent == 0 {
+		fs.ContinuationIndent = cmd.ContinuationIndent
+	}
+	if fs.RequiredSuffix == "" {
+		fs.RequiredSuffix = cmd.RequiredSuffix
+	}
+	if fs.DefaultLeader == "" {
+		fs.DefaultLeader = cmd.DefaultLeader
+	}
+	if fs.DefaultFormatter == nil {
+		fs.DefaultFormatter = cmd.DefaultFormatter
+	}
+	fs.SortFlags = fs.SortFlags || cmd.SortFlags
+	fs.QuoteStringDefaults = fs.QuoteStringDefaults || cmd.QuoteStringDefaults
+	fs.ShowEnvInUsage = fs.ShowEnvInUsage || cmd.ShowEnvInUsage
+	fs.ShowExamples = fs.ShowExamples || cmd.ShowExamples
+	fs.ShowTypes = fs.ShowTypes || cmd.ShowTypes
+	fs.ShowBoolTypes = fs.ShowBoolTypes || cmd.ShowBoolTypes
+	fs.HideDefaults = fs.HideDefaults || cmd.HideDefaults
+
+	fs.Enabled = true
+	fs.cmd = cmd
+
 	cmd.flagSets = append(cmd.flagSets, fs)
 
-	return fs
+	return nil
+}
+
+// SetOrder reorders the FlagSets so that help output shows the ones with the
+// given names or aliases first, in the given order, followed by the others
+// in their current order. Unknown names are ignored, so the order can list
+// FlagSets that are only created under some conditions.
+func (cmd *Command) SetOrder(names ...string) {
+	ordered := make([]*FlagSet, 0, len(cmd.flagSets))
+	for _, name := range names {
+		if fs := cmd.lookupFlagSet(name); fs != nil && !slices.Contains(ordered, fs) {
+			ordered = append(ordered, fs)
+		}
+	}
+
+	for _, fs := range cmd.flagSets {
+		if !slices.Contains(ordered, fs) {
+			ordered = append(ordered, fs)
+		}
+	}
+
+	cmd.flagSets = ordered
 }
 
 // Parse processes command line arguments according to the defined flags.
-// It returns an error if flag parsing fails.
+// It returns ErrHelp after printing help if help was requested, or an error
+// if flag parsing fails.
 func (cmd *Command) Parse() error {
-	pflag.CommandLine = pflag.NewFlagSet("", pflag.ContinueOnError)
-	pflag.Usage = cmd.Usage
+	return cmd.ParseArgs(os.Args[1:])
+}
 
-	for _, fs := range cmd.flagSets {
-		pflag.CommandLine.AddFlagSet(fs.FlagSet)
+// ParseArgs processes args, which should not include the program name,
+// according to the defined flags. It returns ErrHelp after printing help
+// if help was requested, or an error if flag parsing fails.
+func (cmd *Command) ParseArgs(args []string) error {
+	return cmd.parse(args, false)
+}
+
+// ParseSilent processes args according to the defined flags without any side
+// effect: it never prints help or warnings, never exits, and leaves the global
+// pflag state alone. It returns ErrHelp if help was requested, or the parsing
+// error otherwise. This is the entry point to use when embedding a Command in
+// a library.
+func (cmd *Command) ParseSilent(args []string) error {
+	return cmd.parse(args, true)
+}
+
+// parse expands the response files in args if ResponseFiles is set, then
+// processes them with parseCommand.
+func (cmd *Command) parse(args []string, silent bool) error {
+	if cmd.ResponseFiles {
+		var err error
+		if args, err = expandResponseFiles(args, 0); err != nil {
+			return err
+		}
+	}
+
+	return cmd.parseCommand(args, silent)
+}
+
+// parseCommand processes args according to the defined flags, handing them
+// over to the subcommand they name if any. Abbreviated flags are expanded
+// against the flags of the Command that parses them. If silent is set,
+// nothing is written to Writer: help, the version and parsing errors are only
+// reported through the returned error.
+func (cmd *Command) parseCommand(args []string, silent bool) error {
+	var err error
+
+	// Abbreviated flags before the subcommand, so that it can be found
+	if cmd.AllowPrefixMatch && len(cmd.commands) > 0 {
+		if args, err = expandPrefixes(cmd.mergeFlagSets(), args, false); err != nil {
+			return err
+		}
+	}
+
+	var i int
+	if cmd.subcommand, i = cmd.findCommand(args); cmd.subcommand != nil {
+		return cmd.subcommand.parseCommand(slices.Delete(slices.Clone(args), i, i+1), silent)
+	}
+
+	out := cmd.Writer
+	fs := cmd.mergeFlagSets()
+	if silent {
+		out = io.Discard
+		fs.Usage = func() {}
+		fs.SetOutput(out)
+	}
+	cmd.flags = fs
+
+	if cmd.AllowPrefixMatch {
+		if args, err = expandPrefixes(fs, args, cmd.InterspersedArgs); err != nil {
+			return err
+		}
+	}
+
+	if format, ok := helpRequested(fs, cmd.flagArgs(fs, args)); ok {
+		return cmd.printHelp(format, silent)
+	}
+
+	if err := cmd.checkCommand(fs, args); err != nil {
+		return err
+	}
+
+	cmd.unknownFlags = nil
+	if cmd.IgnoreUnknownFlags {
+		cmd.unknownFlags = collectUnknownFlags(fs, cmd.flagArgs(fs, args))
+	}
+
+	if err := cmd.parseFlags(fs, args); err != nil {
+		return err
 	}
 
-	if err := pflag.CommandLine.Parse(os.Args[1:]); err != nil {
-		if errors.Is(err, pflag.ErrHelp) {
-			os.Exit(0)
+	if c := cmd.versionRequested(); c != nil {
+		c.PrintVersion(out)
+		return ErrVersion
+	}
+
+	// Help requested with "help", or for a subcommand, help topic or
+	// FlagSet with "help <name>"
+	if cmd.NArg() <= 2 && cmd.Arg(0) == "help" {
+		if err := cmd.helpCommand(out, cmd.args[1:], silent); err != nil {
+			return err
 		}
+	}
+
+	if cmd.action != nil {
+		return cmd.action(silent)
+	}
+
+	if err := cmd.applyEnv(); err != nil {
 		return err
 	}
 
+	return cmd.validateFlags()
+}
+
+// ParseFlagsOnly processes the flags in args and returns every non-flag
+// argument untouched, in order. Unlike Parse, it does not update the
+// positional arguments returned by Args and leaves the global pflag state
+// alone, which makes it suitable for a first parsing pass. It returns
+// ErrHelp if help was requested.
+func (cmd *Command) ParseFlagsOnly(args []string) (remaining []string, err error) {
+	fs := cmd.mergeFlagSets()
+
+	if args, err = cmd.preprocessArgs(fs, args); err != nil {
+		return nil, err
+	}
+
+	if format, ok := helpRequested(fs, cmd.flagArgs(fs, args)); ok {
+		return nil, cmd.printHelp(format, false)
+	}
+
+	cmd.unknownFlags = nil
+	if cmd.IgnoreUnknownFlags {
+		cmd.unknownFlags = collectUnknownFlags(fs, cmd.flagArgs(fs, args))
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	syncAliases(fs)
+
+	return fs.Args(), nil
+}
+
+// preprocessArgs returns args with the response files expanded if
+// ResponseFiles is set, and the abbreviated flags of fs expanded if
+// AllowPrefixMatch is set.
+func (cmd *Command) preprocessArgs(fs *pflag.FlagSet, args []string) ([]string, error) {
+	var err error
+	if cmd.ResponseFiles {
+		if args, err = expandResponseFiles(args, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	if cmd.AllowPrefixMatch {
+		if args, err = expandPrefixes(fs, args, cmd.InterspersedArgs); err != nil {
+			return nil, err
+		}
+	}
+
+	return args, nil
+}
+
+// parseFlags parses args with fs and sets the positional arguments. When
+// PassUnknownFlags is set along with IgnoreUnknownFlags, the unknown flags
+// are kept in the positional arguments, in their original position.
+func (cmd *Command) parseFlags(fs *pflag.FlagSet, args []string) error {
+	if !cmd.IgnoreUnknownFlags || !cmd.PassUnknownFlags {
+		if err := fs.Parse(args); err != nil {
+			return cmd.suggestFlag(err)
+		}
+		syncAliases(fs)
+
+		cmd.args = fs.Args()
+		return nil
+	}
+
+	n := len(cmd.flagArgs(fs, args))
+	known, rest := splitUnknownFlags(fs, args[:n])
+	rest = append(rest, args[n:]...)
+	if err := fs.Parse(known); err != nil {
+		return cmd.suggestFlag(err)
+	}
+	syncAliases(fs)
+
+	cmd.args = append(fs.Args(), rest...)
+	return nil
+}
+
+// versionRequested returns the Command, either cmd or one of its parents,
+// whose --version flag was given during the last parse, or nil if none was.
+func (cmd *Command) versionRequested() *Command {
+	for c := cmd; c != nil; c = c.parent {
+		if c.versionFlag != nil && c.versionFlag.Changed {
+			return c
+		}
+	}
 	return nil
 }
 
+// hasVersionFlag returns the --version flag of the Command, creating it if
+// needed, or nil if Version is not set, DisableVersionFlag is set, or a flag
+// named "version" is defined in the Command or its parents.
+func (cmd *Command) hasVersionFlag() *pflag.Flag {
+	if cmd.Version == "" || cmd.DisableVersionFlag {
+		return nil
+	}
+
+	for c := cmd; c != nil; c = c.parent {
+		for _, fs := range c.flagSets {
+			if fs.Lookup(versionFlag) != nil {
+				return nil
+			}
+		}
+	}
+
+	if cmd.versionFlag == nil {
+		fs := pflag.NewFlagSet(versionFlag, pflag.ContinueOnError)
+		fs.Bool(versionFlag, false, "Print the version and exit")
+		cmd.versionFlag = fs.Lookup(versionFlag)
+	}
+	return cmd.versionFlag
+}
+
+// placeVersionFlag shows the --version flag of the Command, if it has one,
+// after the flags of the first enabled FlagSet with visible flags. The flag
+// is not added to that FlagSet, so that the user can still define a
+// "version" flag of their own. If no FlagSet has visible flags, it returns
+// the unnamed FlagSet holding the flag, to be shown after the others.
+func (cmd *Command) placeVersionFlag() *FlagSet {
+	for _, fs := range cmd.flagSets {
+		fs.extraFlags = nil
+	}
+
+	f := cmd.hasVersionFlag()
+	if f == nil {
+		return nil
+	}
+
+	for _, fs := range cmd.flagSets {
+		if fs.Enabled && fs.maxNameLength(nil) > 0 {
+			fs.extraFlags = []*pflag.Flag{f}
+			return nil
+		}
+	}
+
+	if cmd.versionGroup == nil {
+		cmd.versionGroup = cmd.newFlagSet("")
+		cmd.versionGroup.AddFlag(f)
+	}
+	return cmd.versionGroup
+}
+
+// flagArgs returns the part of args in which flags are parsed: all of args,
+// or the arguments before the first positional one if InterspersedArgs is
+// not set.
+func (cmd *Command) flagArgs(fs *pflag.FlagSet, args []string) []string {
+	if cmd.InterspersedArgs {
+		return args
+	}
+
+	if i := firstPositional(fs, args); i >= 0 {
+		return args[:i]
+	}
+	return args
+}
+
+// mergeFlagSets returns a new pflag.FlagSet containing the flags of every
+// enabled FlagSet, including those of the parents of a subcommand, configured
+// according to the Command settings. Parsing errors and deprecation warnings
+// are written to Writer.
+func (cmd *Command) mergeFlagSets() *pflag.FlagSet {
+	merged := pflag.NewFlagSet(cmd.Name, pflag.ContinueOnError)
+	merged.ParseErrorsWhitelist.UnknownFlags = cmd.IgnoreUnknownFlags
+	merged.Usage = cmd.showUsage
+	merged.SetOutput(cmd.Writer)
+	merged.SetInterspersed(cmd.InterspersedArgs)
+
+	for _, fs := range cmd.enabledFlagSets() {
+		merged.AddFlagSet(fs.FlagSet)
+		for _, f := range fs.extraFlags {
+			if merged.Lookup(f.Name) == nil {
+				merged.AddFlag(f)
+			}
+		}
+	}
+
+	return merged
+}
+
+// Reset restores every flag of the Command and its subcommands to its default
+// value, clears their changed state, and forgets the arguments, unknown flags
+// and subcommand of the last parse, so that the Command can parse again as if
+// newly created. Callbacks registered with OnSet and validators are not called.
+func (cmd *Command) Reset() {
+	for _, fs := range cmd.flagSets {
+		fs.VisitAll(func(f *pflag.Flag) {
+			resetFlag(f)
+		})
+	}
+	if cmd.versionFlag != nil {
+		resetFlag(cmd.versionFlag)
+	}
+
+	for _, sub := range cmd.commands {
+		sub.Reset()
+	}
+
+	cmd.flags = nil
+	cmd.args = nil
+	cmd.unknownFlags = nil
+	cmd.envFlags = nil
+	cmd.configFlags = nil
+	cmd.subcommand = nil
+}
+
 // NArg returns the number of arguments remaining after flags have been processed.
 func (cmd *Command) NArg() int {
-	return pflag.CommandLine.NArg()
+	return len(cmd.args)
 }
 
 // Arg returns the nth argument remaining after flags have been processed.
+// It returns an empty string if the argument does not exist.
 func (cmd *Command) Arg(n int) string {
-	return pflag.CommandLine.Arg(n)
+	if n < 0 || n >= len(cmd.args) {
+		return ""
+	}
+	return cmd.args[n]
 }
 
 // Args returns the non-flag positional arguments.
 func (cmd *Command) Args() []string {
-	return pflag.CommandLine.Args()
+	return cmd.args
+}
+
+// UnknownFlags returns the unknown flags, along with any value pflag consumed
+// for them, collected during the last Parse when IgnoreUnknownFlags is set.
+func (cmd *Command) UnknownFlags() []string {
+	return cmd.unknownFlags
+}
+
+// ValidateChoice returns an *InvalidValueError if the current value of the
+// named flag is not one of the allowed values. It is meant to be called
+// after parsing.
+func (cmd *Command) ValidateChoice(name string, allowed ...string) error {
+	f := cmd.lookupFlag(name)
+	if f == nil {
+		return fmt.Errorf("no such flag --%s", name)
+	}
+
+	value := f.Value.String()
+	if slices.Contains(allowed, value) {
+		return nil
+	}
+
+	return newInvalidValueError(name, value, allowed)
+}
+
+// FlagAnnotation returns the values of the annotation key set on the named
+// flag with FlagSet.SetAnnotation, searching every FlagSet. It returns nil
+// if the flag or the annotation does not exist.
+func (cmd *Command) FlagAnnotation(name, key string) []string {
+	f := cmd.lookupFlag(name)
+	if f == nil {
+		return nil
+	}
+	return f.Annotations[key]
+}
+
+// WalkFlags calls fn for each flag of each FlagSet, in the order they are
+// shown in help output, until fn returns false. The name of the FlagSet
+// containing the flag is passed as group.
+func (cmd *Command) WalkFlags(fn func(group string, f *pflag.Flag) bool) {
+	for _, fs := range cmd.orderedFlagSets() {
+		stop := false
+		fs.visitAllFlags(func(f *pflag.Flag) {
+			if stop {
+				return
+			}
+			stop = !fn(fs.Name, f)
+		})
+
+		if stop {
+			return
+		}
+	}
+}
+
+// SetBuildInfo sets the build information, such as the commit or the build
+// date, shown below the version by PrintVersion.
+func (cmd *Command) SetBuildInfo(info map[string]string) {
+	cmd.buildInfo = maps.Clone(info)
 }
 
-// Usage prints formatted help text to the configured Writer.
+// VersionString returns the program name and version separated by a space,
+// or only the one that is set.
+func (cmd *Command) VersionString() string {
+	return strings.TrimSpace(cmd.Name + " " + cmd.Version)
+}
+
+// PrintVersion writes the program name and version to w, followed by the
+// build information with its values aligned. Keys are sorted alphabetically.
+func (cmd *Command) PrintVersion(w io.Writer) {
+	bw := bufio.NewWriter(w)
+
+	// Program name and version
+	if version := cmd.VersionString(); version != "" {
+		writeString(bw, version)
+		writeByte(bw, '\n')
+	}
+
+	// Build information
+	keys := slices.Sorted(maps.Keys(cmd.buildInfo))
+
+	var maxKeyLen int
+	for _, key := range keys {
+		maxKeyLen = max(maxKeyLen, len(key))
+	}
+
+	indentation := strings.Repeat(" ", cmd.Indentation)
+	for _, key := range keys {
+		writeString(bw, indentation)
+		writeString(bw, key)
+		writeByte(bw, ':')
+		writeString(bw, strings.Repeat(" ", maxKeyLen-len(key)+1))
+		writeString(bw, cmd.buildInfo[key])
+		writeByte(bw, '\n')
+	}
+
+	bw.Flush()
+}
+
+// Usage prints formatted help text to the configured Writer, through the
+// Pager if one is set and Writer is a terminal.
 func (cmd *Command) Usage() {
+	if cmd.Pager != "" && isTerminal(cmd.Writer) {
+		if err := cmd.page(cmd.UsageString()); err == nil {
+			return
+		}
+	}
+
+	cmd.writeUsage(cmd.Writer, nil)
+}
+
+// showUsage prints help through UsageFunc if it is set, or Usage otherwise.
+func (cmd *Command) showUsage() {
+	if cmd.UsageFunc != nil {
+		cmd.UsageFunc(cmd)
+		return
+	}
+	cmd.Usage()
+}
+
+// EnableStructuredHelp makes the help flag accept the format of the help
+// output as value, for tooling: "--help=json" writes the flags as returned by
+// FlagsJSON, "--help=markdown" writes the reference returned by Markdown, and
+// "--help=text" or a plain "--help" prints help as usual. Parsing returns
+// ErrHelp in every case, or an error for an unknown format. It applies to the
+// subcommands too.
+func (cmd *Command) EnableStructuredHelp() {
+	cmd.structuredHelp = true
+}
+
+// printHelp writes the help output in format, as requested with the help
+// flag, and returns ErrHelp. Unless structured help is enabled on the
+// Command or one of its parents, the format is ignored and help is printed
+// as text. Nothing is written if silent is set.
+func (cmd *Command) printHelp(format string, silent bool) error {
+	structured := false
+	for c := cmd; c != nil; c = c.parent {
+		structured = structured || c.structuredHelp
+	}
+	if !structured {
+		format = ""
+	}
+
+	var output string
+	switch format {
+	case "", "text":
+	case "json":
+		b, err := cmd.FlagsJSON(false)
+		if err != nil {
+			return err
+		}
+		output = string(b) + "\n"
+	case "markdown":
+		output = cmd.Markdown() + "\n"
+	default:
+		return fmt.Errorf("invalid help format %q: must be text, json or markdown", format)
+	}
+
+	if silent {
+		return ErrHelp
+	}
+
+	if output == "" {
+		cmd.showUsage()
+	} else {
+		io.WriteString(cmd.Writer, output)
+	}
+	return ErrHelp
+}
+
+// UsageError writes "Error: " followed by the formatted message to Writer,
+// then prints help, and returns the message as an error.
+func (cmd *Command) UsageError(format string, args ...any) error {
+	err := fmt.Errorf(format, args...)
+	fmt.Fprintf(cmd.Writer, "Error: %v\n", err)
+	cmd.showUsage()
+	return err
+}
+
+// FailUsage is like UsageError, but exits with UsageExitCode instead of
+// returning.
+func (cmd *Command) FailUsage(format string, args ...any) {
+	cmd.UsageError(format, args...)
+	os.Exit(cmd.UsageExitCode)
+}
+
+// UsageString returns the formatted help text.
+func (cmd *Command) UsageString() string {
+	sb := strings.Builder{}
+	cmd.writeUsage(&sb, nil)
+	return sb.String()
+}
+
+// UsageFiltered prints formatted help text to w, only showing the flags for
+// which match returns true. FlagSets without any matching flag are omitted.
+func (cmd *Command) UsageFiltered(w io.Writer, match func(*pflag.Flag) bool) {
+	cmd.writeUsage(w, match)
+}
+
+// PflagUsageString returns the flags of every FlagSet formatted by pflag's
+// native FlagUsages, bypassing the formatting of this package.
+func (cmd *Command) PflagUsageString() string {
+	return cmd.mergeFlagSets().FlagUsages()
+}
+
+// page pipes s through the Pager to the Writer. It returns an error if the
+// Pager cannot be started, in which case nothing has been written.
+func (cmd *Command) page(s string) error {
+	args := strings.Fields(cmd.Pager)
+	if len(args) == 0 {
+		return errors.New("empty pager command")
+	}
+
+	pager := exec.Command(args[0], args[1:]...)
+	pager.Stdin = strings.NewReader(s)
+	pager.Stdout = cmd.Writer
+	pager.Stderr = os.Stderr
+
+	if err := pager.Start(); err != nil {
+		return err
+	}
+
+	// The help has been handed over to the pager at this point, so a
+	// failure while paging is not reported.
+	pager.Wait()
+	return nil
+}
+
+// writeUsage writes the formatted help text to out. If match is not nil,
+// only the flags for which it returns true are shown.
+func (cmd *Command) writeUsage(out io.Writer, match func(*pflag.Flag) bool) {
+	// Custom template, falling back to the default output if it fails
+	if cmd.Template != nil && match == nil {
+		if err := cmd.writeTemplate(out); err == nil {
+			return
+		}
+	}
+
 	var n int
-	w := bufio.NewWriter(cmd.Writer)
+	var sb strings.Builder
+	w := bufio.NewWriter(&sb)
+	colors := cmd.activeColors()
 
 	// Program name
 	if cmd.Name != "" {
@@ -151,40 +1079,383 @@ func (cmd *Command) Usage() {
 		if n != 0 {
 			n += writeByte(w, '\n')
 		}
-		n += writeString(w, cmd.Description)
+		for i, line := range strings.Split(cmd.Description, "\n") {
+			if i != 0 {
+				n += writeByte(w, '\n')
+			}
+			n += writeString(w, paint(line, colors.Description))
+		}
 		n += writeByte(w, '\n')
 	}
 
-	// Calculate the length of the longest flag name in all the FlagSets
-	var maxNameLen int
-	for _, fs := range cmd.flagSets {
-		maxNameLen = max(maxNameLen, fs.maxNameLength())
+	// Synopsis
+	if cmd.ShowSynopsis {
+		if synopsis := cmd.wrapSynopsis("Usage: ", cmd.width()); synopsis != "" {
+			if n != 0 {
+				n += writeByte(w, '\n')
+			}
+			n += writeString(w, synopsis)
+			n += writeByte(w, '\n')
+		}
 	}
 
-	for _, fs := range cmd.flagSets {
+	// Summary of the required flags
+	if cmd.ShowRequiredSummary && match == nil {
+		if summary := cmd.requiredSummary(); summary != "" {
+			if n != 0 {
+				n += writeByte(w, '\n')
+			}
+			n += writeString(w, summary)
+			n += writeByte(w, '\n')
+		}
+	}
+
+	// Index of the FlagSets
+	if cmd.ShowGroupIndex {
+		if index := cmd.groupIndex(match); index != "" {
+			if n != 0 {
+				n += writeByte(w, '\n')
+			}
+			n += writeString(w, index)
+			n += writeByte(w, '\n')
+		}
+	}
+
+	// Subcommands
+	if commands := cmd.commandsString(); match == nil && commands != "" {
+		if n != 0 {
+			n += writeByte(w, '\n')
+		}
+		n += writeString(w, commands)
+	}
+
+	// Calculate the length of the longest flag name in all the FlagSets.
+	// FlagSets without visible flags, such as description-only groups,
+	// contribute nothing and do not affect the alignment.
+	flagSets := cmd.shownFlagSets()
+	maxNameLen := cmd.globalMaxNameLength(flagSets, match)
+	width := cmd.width()
+
+	var groups int
+	for _, fs := range flagSets {
 		// Calculate the length of the longest flag name in the current FlagSet
-		fsMaxNameLen := fs.maxNameLength()
+		fsMaxNameLen := fs.maxNameLength(match)
 
 		// Skip the FlagSet if there is nothing to output.
-		if fsMaxNameLen == 0 && fs.Description == "" && fs.Footer == "" {
+		if fsMaxNameLen == 0 && (match != nil || fs.Description == "" && fs.Footer == "") {
 			continue
 		}
 
-		if cmd.AlignUsagePerFlagSet {
-			maxNameLen = fsMaxNameLen
-		}
+		// Apply the proper padding and width
+		cmd.layoutFlagSet(fs, maxNameLen, width, colors, match)
 
-		// Apply the proper padding
-		fs.computePadding(maxNameLen)
+		// Write the FlagSet, separated from the previous one
+		if groups != 0 {
+			n += writeString(w, cmd.GroupSeparator)
+		} else if n != 0 {
+			n += writeByte(w, '\n')
+		}
+		n += writeString(w, fs.toString(match))
+		groups++
+	}
 
-		// Write the FlagSet
+	// Help topics
+	if match == nil && len(cmd.topics) > 0 {
 		if n != 0 {
 			n += writeByte(w, '\n')
 		}
-		n += writeString(w, fs.ToString())
+		n += writeString(w, cmd.topicsString())
 	}
 
 	w.Flush()
+	io.WriteString(out, trimTrailingSpace(sb.String()))
+}
+
+// groupIndex returns a single line listing the name of each FlagSet shown in
+// help output along with its number of visible flags. FlagSets without
+// visible flags or without a visible name are omitted.
+func (cmd *Command) groupIndex(match func(*pflag.Flag) bool) string {
+	var entries []string
+	for _, fs := range cmd.shownFlagSets() {
+		if fs.Name == "" || fs.HideName {
+			continue
+		}
+
+		var count int
+		fs.visitFlags(match, func(*pflag.Flag) {
+			count++
+		})
+		if count == 0 {
+			continue
+		}
+
+		entries = append(entries, fmt.Sprintf("%s (%d)", fs.Name, count))
+	}
+
+	return strings.Join(entries, ", ")
+}
+
+// requiredSummary returns the line listing the visible required flags of
+// the shown FlagSets, e.g. "Required: --db-host, --db-user", or an empty
+// string if there is none.
+func (cmd *Command) requiredSummary() string {
+	var names []string
+	for _, fs := range cmd.shownFlagSets() {
+		fs.VisitAll(func(f *pflag.Flag) {
+			if isRequired(f) && !f.Hidden {
+				names = append(names, "--"+f.Name)
+			}
+		})
+	}
+
+	if len(names) == 0 {
+		return ""
+	}
+	return "Required: " + strings.Join(names, ", ")
+}
+
+// helpRequested returns whether args contains a help flag, in any of its
+// forms, that is not defined in fs, along with the value given to the long
+// form, e.g. "json" for "--help=json". Arguments consumed as flag values are
+// skipped so that they are not mistaken for a help flag.
+func helpRequested(fs *pflag.FlagSet, args []string) (string, bool) {
+	var value string
+	var found bool
+	scanArgs(fs, args, nil, func(t argToken) bool {
+		if t.kind == argTerminator {
+			return false
+		}
+
+		for _, f := range t.flags {
+			if f.help {
+				value, found = f.value, true
+				return false
+			}
+		}
+		return true
+	})
+
+	return value, found
+}
+
+// splitUnknownFlags separates the known flags in args, along with their
+// values, from the rest of the arguments, which are returned in order.
+// Combined shorthands such as "-vx" are only known if every letter is.
+// The "--" separator and the arguments after it are all part of the rest.
+func splitUnknownFlags(fs *pflag.FlagSet, args []string) (known, rest []string) {
+	scanArgs(fs, args, nil, func(t argToken) bool {
+		switch {
+		case t.kind == argTerminator:
+			rest = append(rest, args[t.index:]...)
+			return false
+		case t.kind != argPositional && t.known():
+			known = append(known, args[t.index:t.end]...)
+		default:
+			rest = append(rest, args[t.index:t.end]...)
+		}
+		return true
+	})
+
+	return known, rest
+}
+
+// collectUnknownFlags returns the arguments that pflag discards when it is
+// configured to ignore unknown flags. It mirrors the way pflag consumes
+// arguments so that the value of an unknown flag is collected along with it.
+func collectUnknownFlags(fs *pflag.FlagSet, args []string) []string {
+	var unknown []string
+	scanArgs(fs, args, nil, func(t argToken) bool {
+		if t.kind == argTerminator {
+			return false
+		}
+
+		for _, f := range t.flags {
+			if f.flag != nil || f.help {
+				continue
+			}
+
+			switch {
+			case t.kind == argLong:
+				unknown = append(unknown, args[t.index])
+			case f.hasValue:
+				unknown = append(unknown, "-"+f.name+"="+f.value)
+			default:
+				unknown = append(unknown, "-"+f.name)
+			}
+
+			if f.valueIndex >= 0 {
+				unknown = append(unknown, args[f.valueIndex])
+			}
+		}
+		return true
+	})
+
+	return unknown
+}
+
+// lookupFlag returns the flag with the given name from any FlagSet,
+// or nil if no such flag exists.
+func (cmd *Command) lookupFlag(name string) *pflag.Flag {
+	for _, fs := range cmd.flagSets {
+		if f := fs.Lookup(name); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+// checkFlagConflicts returns an error if a flag of fs has the same name or
+// shorthand as a flag of the Command or its parents.
+func (cmd *Command) checkFlagConflicts(fs *FlagSet) error {
+	var err error
+	fs.VisitAll(func(f *pflag.Flag) {
+		if err != nil {
+			return
+		}
+
+		for c := cmd; c != nil; c = c.parent {
+			if c.lookupFlag(f.Name) != nil {
+				err = fmt.Errorf("flag --%s already exists", f.Name)
+				return
+			}
+			if f.Shorthand != "" && c.lookupShorthand(f.Shorthand) != nil {
+				err = fmt.Errorf("shorthand flag -%s of --%s already exists", f.Shorthand, f.Name)
+				return
+			}
+		}
+	})
+	return err
+}
+
+// lookupShorthand returns the flag with the given shorthand in any FlagSet,
+// or nil if no such flag exists.
+func (cmd *Command) lookupShorthand(shorthand string) *pflag.Flag {
+	for _, fs := range cmd.flagSets {
+		if f := fs.ShorthandLookup(shorthand); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+// RenderFlagSet returns the FlagSet with the given name or alias formatted
+// and aligned the same way as in the full help output. It returns an error
+// if no such FlagSet exists.
+func (cmd *Command) RenderFlagSet(name string) (string, error) {
+	fs := cmd.lookupFlagSet(name)
+	if fs == nil {
+		return "", fmt.Errorf("no such flag set %q", name)
+	}
+
+	return cmd.flagSetString(fs), nil
+}
+
+// FlagSets returns the FlagSets of the Command in their current order. The
+// returned slice is a copy, but the FlagSets are the ones used for parsing
+// and help output.
+func (cmd *Command) FlagSets() []*FlagSet {
+	return slices.Clone(cmd.flagSets)
+}
+
+// LookupFlagSet returns the FlagSet with the given name or alias, or nil if
+// no such FlagSet exists.
+func (cmd *Command) LookupFlagSet(name string) *FlagSet {
+	return cmd.lookupFlagSet(name)
+}
+
+// lookupFlagSet returns the FlagSet with the given name or alias,
+// or nil if no such FlagSet exists.
+func (cmd *Command) lookupFlagSet(name string) *FlagSet {
+	for _, fs := range cmd.flagSets {
+		if fs.hasName(name) {
+			return fs
+		}
+	}
+	return nil
+}
+
+// flagSetString returns the formatted FlagSet, aligned the same way as in
+// the full help output.
+func (cmd *Command) flagSetString(fs *FlagSet) string {
+	maxNameLen := cmd.globalMaxNameLength(cmd.shownFlagSets(), nil)
+	cmd.layoutFlagSet(fs, maxNameLen, cmd.width(), cmd.activeColors(), nil)
+
+	return fs.ToString()
+}
+
+// layoutFlagSet sets the padding, width, environment prefix and colors used
+// to render fs. The usage text is aligned on the flags of fs for which match
+// returns true, or on maxNameLen if fs is aligned globally.
+func (cmd *Command) layoutFlagSet(fs *FlagSet, maxNameLen, width int, colors Colors, match func(*pflag.Flag) bool) {
+	if cmd.alignsPerFlagSet(fs) {
+		fs.computePadding(fs.maxNameLength(match))
+	} else {
+		fs.computePadding(maxNameLen)
+	}
+	fs.computedWidth = width
+	fs.envPrefix = cmd.envPrefixOf(fs)
+	fs.colors = colors
+	fs.exclusive = cmd.exclusiveFlags()
+}
+
+// width returns the number of columns at which usage text is wrapped.
+func (cmd *Command) width() int {
+	if cmd.Width > 0 {
+		return cmd.Width
+	}
+	return detectWidth(cmd.Writer)
+}
+
+// alignsPerFlagSet returns whether the usage text of the FlagSet is aligned
+// on its own flags rather than on the flags of every FlagSet.
+func (cmd *Command) alignsPerFlagSet(fs *FlagSet) bool {
+	if fs.AlignUsage != nil {
+		return !*fs.AlignUsage
+	}
+	return cmd.AlignUsagePerFlagSet
+}
+
+// globalMaxNameLength returns the length of the longest flag name among the
+// FlagSets that are aligned globally. If match is not nil, only the flags for
+// which it returns true are considered.
+func (cmd *Command) globalMaxNameLength(flagSets []*FlagSet, match func(*pflag.Flag) bool) int {
+	var maxNameLen int
+	for _, fs := range flagSets {
+		if cmd.alignsPerFlagSet(fs) {
+			continue
+		}
+		maxNameLen = max(maxNameLen, fs.maxNameLength(match))
+	}
+	return maxNameLen
+}
+
+// orderedFlagSets returns the FlagSets in the order they are shown in help
+// output.
+func (cmd *Command) orderedFlagSets() []*FlagSet {
+	flagSets := slices.Clone(cmd.flagSets)
+	if cmd.ReverseGroups {
+		slices.Reverse(flagSets)
+	}
+
+	if fs := cmd.placeVersionFlag(); fs != nil {
+		flagSets = append(flagSets, fs)
+	}
+	return flagSets
+}
+
+// shownFlagSets returns the FlagSets shown in help output, in display order,
+// followed by those inherited from the parents of a subcommand.
+func (cmd *Command) shownFlagSets() []*FlagSet {
+	flagSets := make([]*FlagSet, 0, len(cmd.flagSets))
+	for c := cmd; c != nil; c = c.parent {
+		for _, fs := range c.orderedFlagSets() {
+			if !fs.Enabled && !cmd.ShowDisabledFlagSets {
+				continue
+			}
+			flagSets = append(flagSets, fs)
+		}
+	}
+	return flagSets
 }
 
 func writeString(w *bufio.Writer, s string) int {
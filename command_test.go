@@ -0,0 +1,1841 @@
+package pflagx
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+// newTestCommand returns a Command named "app" writing to out.
+func newTestCommand(out *bytes.Buffer) *Command {
+	cmd := New()
+	cmd.Name = "app"
+	cmd.Writer = out
+	return cmd
+}
+
+func TestIgnoreUnknownFlags(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		wantUnknown []string
+		wantArgs    []string
+		wantVerbose bool
+	}{
+		{
+			name:        "long flags",
+			args:        []string{"--foo", "--verbose", "--bar=1", "file"},
+			wantUnknown: []string{"--foo", "--bar=1"},
+			wantArgs:    []string{"file"},
+			wantVerbose: true,
+		},
+		{
+			name:        "unknown flag consumes its value",
+			args:        []string{"--foo", "value", "-v"},
+			wantUnknown: []string{"--foo", "value"},
+			wantVerbose: true,
+		},
+		{
+			name:        "unknown flag followed by a flag",
+			args:        []string{"--foo", "--verbose"},
+			wantUnknown: []string{"--foo"},
+			wantVerbose: true,
+		},
+		{
+			name:        "shorthands",
+			args:        []string{"-x", "-vy=1"},
+			wantUnknown: []string{"-x", "-y=1"},
+			wantVerbose: true,
+		},
+		{
+			name:     "terminator",
+			args:     []string{"--", "--foo"},
+			wantArgs: []string{"--foo"},
+		},
+		{
+			name:     "no unknown flag",
+			args:     []string{"file"},
+			wantArgs: []string{"file"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := newTestCommand(&out)
+			cmd.IgnoreUnknownFlags = true
+			verbose := cmd.NewFlagSet("General").BoolP("verbose", "v", false, "Verbose output")
+
+			if err := cmd.ParseArgs(tt.args); err != nil {
+				t.Fatalf("ParseArgs() error = %v", err)
+			}
+			if got := cmd.UnknownFlags(); !slices.Equal(got, tt.wantUnknown) {
+				t.Errorf("UnknownFlags() = %q, want %q", got, tt.wantUnknown)
+			}
+			if got := cmd.Args(); !slices.Equal(got, tt.wantArgs) {
+				t.Errorf("Args() = %q, want %q", got, tt.wantArgs)
+			}
+			if *verbose != tt.wantVerbose {
+				t.Errorf("verbose = %v, want %v", *verbose, tt.wantVerbose)
+			}
+			if out.Len() > 0 {
+				t.Errorf("unexpected output:\n%s", out.String())
+			}
+		})
+	}
+}
+
+func TestPassUnknownFlags(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		wantArgs    []string
+		wantVerbose bool
+	}{
+		{
+			name:        "original order",
+			args:        []string{"run", "--foo", "-v", "file", "--bar=1"},
+			wantArgs:    []string{"run", "--foo", "file", "--bar=1"},
+			wantVerbose: true,
+		},
+		{
+			name:     "values of unknown flags",
+			args:     []string{"--foo", "value", "file", "-x", "1"},
+			wantArgs: []string{"--foo", "value", "file", "-x", "1"},
+		},
+		{
+			name:     "unknown combined shorthands",
+			args:     []string{"-vx", "file"},
+			wantArgs: []string{"-vx", "file"},
+		},
+		{
+			name:     "terminator kept",
+			args:     []string{"exec", "--foo", "--", "cmd", "-v"},
+			wantArgs: []string{"exec", "--foo", "--", "cmd", "-v"},
+		},
+		{
+			name:        "known flags only",
+			args:        []string{"-v", "file"},
+			wantArgs:    []string{"file"},
+			wantVerbose: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := newTestCommand(&out)
+			cmd.IgnoreUnknownFlags = true
+			cmd.PassUnknownFlags = true
+			verbose := cmd.NewFlagSet("General").BoolP("verbose", "v", false, "Verbose output")
+
+			if err := cmd.ParseArgs(tt.args); err != nil {
+				t.Fatalf("ParseArgs() error = %v", err)
+			}
+			if got := cmd.Args(); !slices.Equal(got, tt.wantArgs) {
+				t.Errorf("Args() = %q, want %q", got, tt.wantArgs)
+			}
+			if *verbose != tt.wantVerbose {
+				t.Errorf("verbose = %v, want %v", *verbose, tt.wantVerbose)
+			}
+		})
+	}
+}
+
+func TestInterspersedArgs(t *testing.T) {
+	tests := []struct {
+		name         string
+		interspersed bool
+		args         []string
+		wantArgs     []string
+		wantVerbose  bool
+	}{
+		{
+			name:         "interspersed",
+			interspersed: true,
+			args:         []string{"exec", "-v", "cmd"},
+			wantArgs:     []string{"exec", "cmd"},
+			wantVerbose:  true,
+		},
+		{
+			name:         "interspersed terminator",
+			interspersed: true,
+			args:         []string{"exec", "--", "cmd", "-v"},
+			wantArgs:     []string{"exec", "cmd", "-v"},
+		},
+		{
+			name:        "flags before operands",
+			args:        []string{"-v", "exec", "cmd", "--verbose"},
+			wantArgs:    []string{"exec", "cmd", "--verbose"},
+			wantVerbose: true,
+		},
+		{
+			name:     "operand first",
+			args:     []string{"exec", "-v", "cmd"},
+			wantArgs: []string{"exec", "-v", "cmd"},
+		},
+		{
+			name:        "terminator",
+			args:        []string{"-v", "--", "exec", "cmd", "--its-own-flag"},
+			wantArgs:    []string{"exec", "cmd", "--its-own-flag"},
+			wantVerbose: true,
+		},
+		{
+			name:     "terminator after operand",
+			args:     []string{"exec", "--", "cmd"},
+			wantArgs: []string{"exec", "--", "cmd"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := newTestCommand(&out)
+			cmd.InterspersedArgs = tt.interspersed
+			verbose := cmd.NewFlagSet("General").BoolP("verbose", "v", false, "Verbose output")
+
+			if err := cmd.ParseArgs(tt.args); err != nil {
+				t.Fatalf("ParseArgs() error = %v", err)
+			}
+			if got := cmd.Args(); !slices.Equal(got, tt.wantArgs) {
+				t.Errorf("Args() = %q, want %q", got, tt.wantArgs)
+			}
+			if *verbose != tt.wantVerbose {
+				t.Errorf("verbose = %v, want %v", *verbose, tt.wantVerbose)
+			}
+		})
+	}
+}
+
+func TestUnknownFlagsError(t *testing.T) {
+	var out bytes.Buffer
+	cmd := newTestCommand(&out)
+	cmd.NewFlagSet("General").Bool("verbose", false, "Verbose output")
+
+	if err := cmd.ParseArgs([]string{"--foo"}); err == nil {
+		t.Fatal("ParseArgs() error = nil, want an unknown flag error")
+	}
+	if got := cmd.UnknownFlags(); got != nil {
+		t.Errorf("UnknownFlags() = %q, want nil", got)
+	}
+}
+
+func TestReverseGroups(t *testing.T) {
+	tests := []struct {
+		name    string
+		reverse bool
+		want    string
+	}{
+		{
+			name: "creation order",
+			want: "app\n" +
+				"General:\n" +
+				"  -v, --verbose    Verbose output\n" +
+				"\n" +
+				"Database:\n" +
+				"      --db-host    Database host (default: \"localhost\")\n",
+		},
+		{
+			name:    "reversed",
+			reverse: true,
+			want: "app\n" +
+				"Database:\n" +
+				"      --db-host    Database host (default: \"localhost\")\n" +
+				"\n" +
+				"General:\n" +
+				"  -v, --verbose    Verbose output\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := newTestCommand(&out)
+			cmd.ReverseGroups = tt.reverse
+			cmd.NewFlagSet("General").BoolP("verbose", "v", false, "Verbose output")
+			cmd.NewFlagSet("Database").String("db-host", "localhost", "Database host")
+
+			if got := cmd.UsageString(); got != tt.want {
+				t.Errorf("UsageString() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUsageFiltered(t *testing.T) {
+	tests := []struct {
+		name  string
+		match func(*pflag.Flag) bool
+		want  string
+	}{
+		{
+			name:  "name contains db",
+			match: func(f *pflag.Flag) bool { return strings.Contains(f.Name, "db") },
+			want: "app\n" +
+				"Database:\n" +
+				"      --db-host    Database host (default: \"localhost\")\n" +
+				"      --db-port    Database port (default: 5432)\n",
+		},
+		{
+			name:  "name starts with v or c",
+			match: func(f *pflag.Flag) bool { return strings.HasPrefix(f.Name, "v") || strings.HasPrefix(f.Name, "c") },
+			want: "app\n" +
+				"General:\n" +
+				"  -v, --verbose               Verbose output\n" +
+				"\n" +
+				"Database:\n" +
+				"      --connection-pooling    Pool database connections\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := newTestCommand(&out)
+			cmd.NewFlagSet("General").BoolP("verbose", "v", false, "Verbose output")
+			db := cmd.NewFlagSet("Database")
+			db.String("db-host", "localhost", "Database host")
+			db.Int("db-port", 5432, "Database port")
+			db.Bool("connection-pooling", false, "Pool database connections")
+
+			var got bytes.Buffer
+			cmd.UsageFiltered(&got, tt.match)
+			if got.String() != tt.want {
+				t.Errorf("UsageFiltered() = %q, want %q", got.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFlagsOnly(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          []string
+		wantRemaining []string
+		wantVerbose   bool
+	}{
+		{
+			name:          "positionals verbatim",
+			args:          []string{"serve", "-v", "a", "b"},
+			wantRemaining: []string{"serve", "a", "b"},
+			wantVerbose:   true,
+		},
+		{
+			name:          "terminator",
+			args:          []string{"a", "--", "-v"},
+			wantRemaining: []string{"a", "-v"},
+		},
+		{
+			name:          "too many positionals",
+			args:          []string{"a", "b", "c", "d"},
+			wantRemaining: []string{"a", "b", "c", "d"},
+		},
+		{
+			name: "missing required positional",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := newTestCommand(&out)
+			verbose := cmd.NewFlagSet("General").BoolP("verbose", "v", false, "Verbose output")
+			cmd.AddPositional("source", true)
+			cmd.AddCommand("serve", New())
+
+			remaining, err := cmd.ParseFlagsOnly(tt.args)
+			if err != nil {
+				t.Fatalf("ParseFlagsOnly() error = %v", err)
+			}
+			if !slices.Equal(remaining, tt.wantRemaining) {
+				t.Errorf("ParseFlagsOnly() = %q, want %q", remaining, tt.wantRemaining)
+			}
+			if *verbose != tt.wantVerbose {
+				t.Errorf("verbose = %v, want %v", *verbose, tt.wantVerbose)
+			}
+			if cmd.Args() != nil || cmd.Subcommand() != nil {
+				t.Errorf("Args() = %q, Subcommand() = %v, want them untouched", cmd.Args(), cmd.Subcommand())
+			}
+		})
+	}
+}
+
+func TestPrintVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		info    map[string]string
+		want    string
+	}{
+		{
+			name:    "version only",
+			version: "v1.2.3",
+			want:    "app v1.2.3\n",
+		},
+		{
+			name:    "build info",
+			version: "v1.2.3",
+			info: map[string]string{
+				"commit":    "abc1234",
+				"date":      "2024-01-01",
+				"goversion": "go1.22.0",
+			},
+			want: "app v1.2.3\n" +
+				"  commit:    abc1234\n" +
+				"  date:      2024-01-01\n" +
+				"  goversion: go1.22.0\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := newTestCommand(&out)
+			cmd.Version = tt.version
+			cmd.SetBuildInfo(tt.info)
+
+			var got bytes.Buffer
+			cmd.PrintVersion(&got)
+			if got.String() != tt.want {
+				t.Errorf("PrintVersion() = %q, want %q", got.String(), tt.want)
+			}
+
+			if err := cmd.ParseArgs([]string{"--version"}); !errors.Is(err, ErrVersion) {
+				t.Fatalf("ParseArgs(--version) error = %v, want ErrVersion", err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("--version wrote %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestVersionFlag(t *testing.T) {
+	tests := []struct {
+		name        string
+		version     string
+		disable     bool
+		userFlag    bool
+		wantErr     string
+		wantVersion bool
+		wantOutput  string
+	}{
+		{
+			name:        "version",
+			version:     "v1.2.3",
+			wantVersion: true,
+			wantOutput:  "app v1.2.3\n",
+		},
+		{
+			name:    "disabled",
+			version: "v1.2.3",
+			disable: true,
+			wantErr: "unknown flag: --version",
+		},
+		{
+			name:    "no version",
+			wantErr: "unknown flag: --version",
+		},
+		{
+			name:     "user-defined flag",
+			version:  "v1.2.3",
+			userFlag: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := newTestCommand(&out)
+			cmd.Version = tt.version
+			cmd.DisableVersionFlag = tt.disable
+			fs := cmd.NewFlagSet("General")
+			var userVersion *bool
+			if tt.userFlag {
+				userVersion = fs.Bool("version", false, "Show the API version")
+			}
+
+			err := cmd.ParseArgs([]string{"--version"})
+			switch {
+			case tt.wantVersion && !errors.Is(err, ErrVersion):
+				t.Fatalf("ParseArgs() error = %v, want ErrVersion", err)
+			case !tt.wantVersion && tt.wantErr == "" && err != nil:
+				t.Fatalf("ParseArgs() error = %v", err)
+			case tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr):
+				t.Fatalf("ParseArgs() error = %v, want %q", err, tt.wantErr)
+			}
+			if out.String() != tt.wantOutput {
+				t.Errorf("ParseArgs() wrote %q, want %q", out.String(), tt.wantOutput)
+			}
+			if userVersion != nil && !*userVersion {
+				t.Error("the user-defined --version flag was not set")
+			}
+		})
+	}
+}
+
+func TestVersionString(t *testing.T) {
+	tests := []struct {
+		name    string
+		cmdName string
+		version string
+		want    string
+	}{
+		{name: "name and version", cmdName: "app", version: "v1.2.3", want: "app v1.2.3"},
+		{name: "version only", version: "v1.2.3", want: "v1.2.3"},
+		{name: "name only", cmdName: "app", want: "app"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := New()
+			cmd.Name = tt.cmdName
+			cmd.Version = tt.version
+
+			if got := cmd.VersionString(); got != tt.want {
+				t.Errorf("VersionString() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWalkFlags(t *testing.T) {
+	tests := []struct {
+		name   string
+		stopAt string
+		want   []string
+	}{
+		{
+			name: "complete",
+			want: []string{"General/verbose", "General/quiet", "Database/db-host", "Database/db-port"},
+		},
+		{
+			name:   "stop in first group",
+			stopAt: "quiet",
+			want:   []string{"General/verbose", "General/quiet"},
+		},
+		{
+			name:   "stop in second group",
+			stopAt: "db-host",
+			want:   []string{"General/verbose", "General/quiet", "Database/db-host"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := New()
+			general := cmd.NewFlagSet("General")
+			general.Bool("verbose", false, "Verbose output")
+			general.Bool("quiet", false, "Quiet output")
+			db := cmd.NewFlagSet("Database")
+			db.String("db-host", "localhost", "Database host")
+			db.Int("db-port", 5432, "Database port")
+
+			var got []string
+			cmd.WalkFlags(func(group string, f *pflag.Flag) bool {
+				got = append(got, group+"/"+f.Name)
+				return f.Name != tt.stopAt
+			})
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("WalkFlags() visited %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAlignmentIgnoresDescriptionOnlyGroups(t *testing.T) {
+	tests := []struct {
+		name     string
+		examples bool
+		want     string
+	}{
+		{
+			name: "without description-only group",
+			want: "app\n" +
+				"General:\n" +
+				"  -v, --verbose    Verbose output\n" +
+				"\n" +
+				"Database:\n" +
+				"      --db-host    Database host (default: \"localhost\")\n",
+		},
+		{
+			name:     "with description-only group",
+			examples: true,
+			want: "app\n" +
+				"General:\n" +
+				"  -v, --verbose    Verbose output\n" +
+				"\n" +
+				"Examples:\n" +
+				"  app --verbose --db-host db.example.com --some-long-option\n" +
+				"\n" +
+				"Database:\n" +
+				"      --db-host    Database host (default: \"localhost\")\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := newTestCommand(&out)
+			cmd.NewFlagSet("General").BoolP("verbose", "v", false, "Verbose output")
+			if tt.examples {
+				examples := cmd.NewFlagSet("Examples")
+				examples.Description = "app --verbose --db-host db.example.com --some-long-option"
+			}
+			cmd.NewFlagSet("Database").String("db-host", "localhost", "Database host")
+
+			if got := cmd.UsageString(); got != tt.want {
+				t.Errorf("UsageString() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShowRequiredSummary(t *testing.T) {
+	tests := []struct {
+		name     string
+		show     bool
+		required []string
+		want     string
+	}{
+		{
+			name:     "summary",
+			show:     true,
+			required: []string{"db-host", "db-user"},
+			want: "app\n" +
+				"Test app.\n" +
+				"\n" +
+				"Required: --db-host, --db-user\n" +
+				"\n" +
+				"General:\n" +
+				"  -v, --verbose    Verbose output\n" +
+				"\n" +
+				"Database:\n" +
+				"      --db-host    Database host (required)\n" +
+				"      --db-user    Database user (required)\n",
+		},
+		{
+			name: "no required flag",
+			show: true,
+			want: "app\n" +
+				"Test app.\n" +
+				"\n" +
+				"General:\n" +
+				"  -v, --verbose    Verbose output\n" +
+				"\n" +
+				"Database:\n" +
+				"      --db-host    Database host\n" +
+				"      --db-user    Database user\n",
+		},
+		{
+			name:     "disabled",
+			required: []string{"db-host"},
+			want: "app\n" +
+				"Test app.\n" +
+				"\n" +
+				"General:\n" +
+				"  -v, --verbose    Verbose output\n" +
+				"\n" +
+				"Database:\n" +
+				"      --db-host    Database host (required)\n" +
+				"      --db-user    Database user\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := newTestCommand(&out)
+			cmd.Description = "Test app."
+			cmd.ShowRequiredSummary = tt.show
+			cmd.NewFlagSet("General").BoolP("verbose", "v", false, "Verbose output")
+			db := cmd.NewFlagSet("Database")
+			db.String("db-host", "", "Database host")
+			db.String("db-user", "", "Database user")
+			for _, name := range tt.required {
+				db.MarkRequired(name)
+			}
+
+			if got := cmd.UsageString(); got != tt.want {
+				t.Errorf("UsageString() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepeatedHelp(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "long twice", args: []string{"--help", "--help"}},
+		{name: "short and long", args: []string{"-h", "--help"}},
+		{name: "help before unknown flag", args: []string{"--help", "--unknown"}},
+		{name: "unknown flag before help", args: []string{"--unknown", "-h"}},
+		{name: "combined shorthands", args: []string{"-vh"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := newTestCommand(&out)
+			cmd.NewFlagSet("General").BoolP("verbose", "v", false, "Verbose output")
+
+			if err := cmd.ParseArgs(tt.args); !errors.Is(err, ErrHelp) {
+				t.Fatalf("ParseArgs() error = %v, want ErrHelp", err)
+			}
+			if want := cmd.UsageString(); out.String() != want {
+				t.Errorf("ParseArgs() wrote:\n%s\nwant the usage once:\n%s", out.String(), want)
+			}
+		})
+	}
+}
+
+func TestValidateChoice(t *testing.T) {
+	tests := []struct {
+		name    string
+		flag    string
+		args    []string
+		wantErr string
+	}{
+		{name: "default", flag: "format"},
+		{name: "valid", flag: "format", args: []string{"--format", "json"}},
+		{
+			name:    "invalid",
+			flag:    "format",
+			args:    []string{"--format", "markdown"},
+			wantErr: `invalid value "markdown" for --format: must be one of "text", "json", "yaml"`,
+		},
+		{
+			name:    "typo",
+			flag:    "format",
+			args:    []string{"--format", "jsno"},
+			wantErr: `invalid value "jsno" for --format; did you mean "json"?`,
+		},
+		{name: "missing flag", flag: "missing", wantErr: "no such flag --missing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := New()
+			cmd.NewFlagSet("Output").String("format", "text", "Output format")
+
+			if err := cmd.ParseSilent(tt.args); err != nil {
+				t.Fatalf("ParseSilent() error = %v", err)
+			}
+
+			err := cmd.ValidateChoice(tt.flag, "text", "json", "yaml")
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateChoice() error = %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("ValidateChoice() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStructuredHelp(t *testing.T) {
+	tests := []struct {
+		name    string
+		enable  bool
+		args    []string
+		want    func(cmd *Command) string
+		wantErr bool
+	}{
+		{
+			name:   "plain help",
+			enable: true,
+			args:   []string{"--help"},
+			want:   (*Command).UsageString,
+		},
+		{
+			name:   "text",
+			enable: true,
+			args:   []string{"--help=text"},
+			want:   (*Command).UsageString,
+		},
+		{
+			name:   "json",
+			enable: true,
+			args:   []string{"--help=json"},
+			want: func(cmd *Command) string {
+				b, _ := cmd.FlagsJSON(false)
+				return string(b) + "\n"
+			},
+		},
+		{
+			name:   "markdown",
+			enable: true,
+			args:   []string{"--help=markdown"},
+			want:   func(cmd *Command) string { return cmd.Markdown() + "\n" },
+		},
+		{
+			name:    "invalid format",
+			enable:  true,
+			args:    []string{"--help=xml"},
+			want:    func(*Command) string { return "" },
+			wantErr: true,
+		},
+		{
+			name: "format ignored when disabled",
+			args: []string{"--help=json"},
+			want: (*Command).UsageString,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := newTestCommand(&out)
+			cmd.Version = "v1.0.0"
+			if tt.enable {
+				cmd.EnableStructuredHelp()
+			}
+			cmd.NewFlagSet("General").BoolP("verbose", "v", false, "Verbose output")
+
+			err := cmd.ParseArgs(tt.args)
+			switch {
+			case tt.wantErr && (err == nil || errors.Is(err, ErrHelp)):
+				t.Fatalf("ParseArgs() error = %v, want a format error", err)
+			case !tt.wantErr && !errors.Is(err, ErrHelp):
+				t.Fatalf("ParseArgs() error = %v, want ErrHelp", err)
+			}
+
+			if want := tt.want(cmd); out.String() != want {
+				t.Errorf("ParseArgs() wrote:\n%s\nwant:\n%s", out.String(), want)
+			}
+		})
+	}
+}
+
+func TestReset(t *testing.T) {
+	var out bytes.Buffer
+	cmd := newTestCommand(&out)
+	cmd.IgnoreUnknownFlags = true
+	fs := cmd.NewFlagSet("General")
+	verbose := fs.BoolP("verbose", "v", false, "Verbose output")
+	host := fs.String("host", "localhost", "Server host")
+	cmd.AddPositional("source", false)
+
+	if err := cmd.ParseArgs([]string{"-v", "--host", "example.com", "--unknown=1", "file"}); err != nil {
+		t.Fatalf("ParseArgs() error = %v", err)
+	}
+	if cmd.NArg() != 1 || len(cmd.UnknownFlags()) != 1 {
+		t.Fatalf("NArg() = %d, UnknownFlags() = %q, want one of each", cmd.NArg(), cmd.UnknownFlags())
+	}
+
+	cmd.Reset()
+
+	if cmd.NArg() != 0 || cmd.Args() != nil || cmd.Positional("source") != "" {
+		t.Errorf("NArg() = %d, Args() = %q after Reset, want none", cmd.NArg(), cmd.Args())
+	}
+	if cmd.UnknownFlags() != nil {
+		t.Errorf("UnknownFlags() = %q after Reset, want nil", cmd.UnknownFlags())
+	}
+	if *verbose || *host != "localhost" {
+		t.Errorf("verbose = %v, host = %q after Reset, want the defaults", *verbose, *host)
+	}
+	if fs.Changed("verbose") || fs.Changed("host") {
+		t.Error("flags still changed after Reset")
+	}
+
+	if err := cmd.ParseArgs([]string{"other"}); err != nil {
+		t.Fatalf("ParseArgs() after Reset error = %v", err)
+	}
+	if got := cmd.Args(); !slices.Equal(got, []string{"other"}) {
+		t.Errorf("Args() = %q, want [other]", got)
+	}
+}
+
+func TestPflagUsageString(t *testing.T) {
+	cmd := New()
+	general := cmd.NewFlagSet("General")
+	general.BoolP("verbose", "v", false, "Verbose output")
+	db := cmd.NewFlagSet("Database")
+	db.String("db-host", "localhost", "Database host")
+	db.Int("db-port", 5432, "Database port")
+
+	// pflag sorts the flags by name
+	want := "      --db-host string   Database host (default \"localhost\")\n" +
+		"      --db-port int      Database port (default 5432)\n" +
+		"  -v, --verbose          Verbose output\n"
+
+	if got := cmd.PflagUsageString(); got != want {
+		t.Errorf("PflagUsageString() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestMixedAlignment(t *testing.T) {
+	aligned, perGroup := true, false
+
+	tests := []struct {
+		name      string
+		perFlag   bool
+		alignment *bool
+		overrides []string
+		want      string
+	}{
+		{
+			name:      "per-group override",
+			alignment: &perGroup,
+			overrides: []string{"Advanced"},
+			want: "app\n" +
+				"General:\n" +
+				"  -v, --verbose           Verbose output\n" +
+				"\n" +
+				"Advanced:\n" +
+				"      --x    Extra\n" +
+				"\n" +
+				"Database:\n" +
+				"      --db-hostname       Database host\n" +
+				"      --db-max-retries    Maximum retries\n",
+		},
+		{
+			name:      "global override",
+			perFlag:   true,
+			alignment: &aligned,
+			overrides: []string{"General", "Advanced"},
+			want: "app\n" +
+				"General:\n" +
+				"  -v, --verbose    Verbose output\n" +
+				"\n" +
+				"Advanced:\n" +
+				"      --x          Extra\n" +
+				"\n" +
+				"Database:\n" +
+				"      --db-hostname       Database host\n" +
+				"      --db-max-retries    Maximum retries\n",
+		},
+		{
+			name: "no override",
+			want: "app\n" +
+				"General:\n" +
+				"  -v, --verbose           Verbose output\n" +
+				"\n" +
+				"Advanced:\n" +
+				"      --x                 Extra\n" +
+				"\n" +
+				"Database:\n" +
+				"      --db-hostname       Database host\n" +
+				"      --db-max-retries    Maximum retries\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := newTestCommand(&out)
+			cmd.AlignUsagePerFlagSet = tt.perFlag
+			cmd.NewFlagSet("General").BoolP("verbose", "v", false, "Verbose output")
+			cmd.NewFlagSet("Advanced").Bool("x", false, "Extra")
+			db := cmd.NewFlagSet("Database")
+			db.String("db-hostname", "", "Database host")
+			db.String("db-max-retries", "", "Maximum retries")
+			for _, name := range tt.overrides {
+				cmd.LookupFlagSet(name).AlignUsage = tt.alignment
+			}
+
+			if got := cmd.UsageString(); got != tt.want {
+				t.Errorf("UsageString() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddFlagSet(t *testing.T) {
+	var out bytes.Buffer
+	cmd := newTestCommand(&out)
+	cmd.Padding = 2
+	cmd.NewFlagSet("General").BoolP("verbose", "v", false, "Verbose output")
+
+	// Built elsewhere, e.g. in a plugin package
+	db := &FlagSet{Name: "Database"}
+	db.FlagSet = pflag.NewFlagSet("Database", pflag.ContinueOnError)
+	host := db.String("db-host", "localhost", "Database host")
+
+	if err := cmd.AddFlagSet(db); err != nil {
+		t.Fatalf("AddFlagSet() error = %v", err)
+	}
+	if db.Indentation != cmd.Indentation || db.Padding != 2 {
+		t.Errorf("Indentation = %d, Padding = %d, want the Command defaults", db.Indentation, db.Padding)
+	}
+
+	want := "app\n" +
+		"General:\n" +
+		"  -v, --verbose  Verbose output\n" +
+		"\n" +
+		"Database:\n" +
+		"      --db-host  Database host (default: \"localhost\")\n"
+	if got := cmd.UsageString(); got != want {
+		t.Errorf("UsageString() =\n%s\nwant:\n%s", got, want)
+	}
+
+	if err := cmd.ParseArgs([]string{"--db-host", "db.example.com"}); err != nil {
+		t.Fatalf("ParseArgs() error = %v", err)
+	}
+	if *host != "db.example.com" {
+		t.Errorf("db-host = %q, want db.example.com", *host)
+	}
+}
+
+func TestAddFlagSetCollisions(t *testing.T) {
+	tests := []struct {
+		name   string
+		define func(fs *FlagSet)
+		onSub  bool
+	}{
+		{
+			name:   "flag set name",
+			define: func(fs *FlagSet) { fs.Name = "General" },
+		},
+		{
+			name:   "flag set alias",
+			define: func(fs *FlagSet) { fs.AddAlias("General") },
+		},
+		{
+			name:   "flag name",
+			define: func(fs *FlagSet) { fs.Bool("verbose", false, "") },
+		},
+		{
+			name:   "shorthand",
+			define: func(fs *FlagSet) { fs.BoolP("very", "v", false, "") },
+		},
+		{
+			name:   "flag of the parent",
+			define: func(fs *FlagSet) { fs.Bool("verbose", false, "") },
+			onSub:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := New()
+			cmd.NewFlagSet("General").BoolP("verbose", "v", false, "Verbose output")
+			target := cmd
+			if tt.onSub {
+				target = New()
+				cmd.AddCommand("serve", target)
+			}
+
+			fs := &FlagSet{Name: "Plugin", FlagSet: pflag.NewFlagSet("Plugin", pflag.ContinueOnError)}
+			tt.define(fs)
+
+			if err := target.AddFlagSet(fs); err == nil {
+				t.Fatal("AddFlagSet() error = nil, want a collision error")
+			}
+			if target.LookupFlagSet("Plugin") != nil {
+				t.Error("the FlagSet was added despite the error")
+			}
+		})
+	}
+}
+
+// TestHelperPager is not a real test: it acts as a fake pager when run by
+// the tests below, writing what it reads to its output after a marker.
+func TestHelperPager(t *testing.T) {
+	if os.Getenv("PFLAGX_TEST_PAGER") != "1" {
+		return
+	}
+
+	io.WriteString(os.Stdout, "paged:\n")
+	io.Copy(os.Stdout, os.Stdin)
+	os.Exit(0)
+}
+
+func TestPager(t *testing.T) {
+	t.Setenv("PFLAGX_TEST_PAGER", "1")
+	fakePager := os.Args[0] + " -test.run=^TestHelperPager$"
+
+	tests := []struct {
+		name    string
+		pager   string
+		want    func(help string) string
+		wantErr bool
+	}{
+		{
+			name:  "fake pager",
+			pager: fakePager,
+			want:  func(help string) string { return "paged:\n" + help },
+		},
+		{
+			name:    "missing pager",
+			pager:   "/nonexistent/pager",
+			want:    func(string) string { return "" },
+			wantErr: true,
+		},
+		{
+			name:    "empty pager",
+			pager:   " ",
+			want:    func(string) string { return "" },
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := newTestCommand(&out)
+			cmd.Pager = tt.pager
+			cmd.NewFlagSet("General").BoolP("verbose", "v", false, "Verbose output")
+			help := cmd.UsageString()
+
+			if err := cmd.page(help); (err != nil) != tt.wantErr {
+				t.Fatalf("page() error = %v, want error %v", err, tt.wantErr)
+			}
+			if want := tt.want(help); out.String() != want {
+				t.Errorf("page() wrote %q, want %q", out.String(), want)
+			}
+		})
+	}
+}
+
+func TestPagerNotTerminal(t *testing.T) {
+	t.Setenv("PFLAGX_TEST_PAGER", "1")
+
+	var out bytes.Buffer
+	cmd := newTestCommand(&out)
+	cmd.Pager = os.Args[0] + " -test.run=^TestHelperPager$"
+	cmd.NewFlagSet("General").BoolP("verbose", "v", false, "Verbose output")
+
+	cmd.Usage()
+	if want := cmd.UsageString(); out.String() != want {
+		t.Errorf("Usage() wrote %q, want %q without the pager", out.String(), want)
+	}
+}
+
+func TestUsageError(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		args   []any
+		want   string
+	}{
+		{name: "plain", format: "missing argument", want: "missing argument"},
+		{name: "formatted", format: "expected %d arguments, got %q", args: []any{2, "a"}, want: `expected 2 arguments, got "a"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := newTestCommand(&out)
+			cmd.NewFlagSet("General").BoolP("verbose", "v", false, "Verbose output")
+
+			err := cmd.UsageError(tt.format, tt.args...)
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("UsageError() error = %v, want %q", err, tt.want)
+			}
+			if want := "Error: " + tt.want + "\n" + cmd.UsageString(); out.String() != want {
+				t.Errorf("UsageError() wrote %q, want %q", out.String(), want)
+			}
+		})
+	}
+}
+
+// TestHelperFailUsage is not a real test: it calls FailUsage with the exit
+// code in PFLAGX_TEST_FAIL_USAGE when run by TestFailUsage.
+func TestHelperFailUsage(t *testing.T) {
+	code, err := strconv.Atoi(os.Getenv("PFLAGX_TEST_FAIL_USAGE"))
+	if err != nil {
+		return
+	}
+
+	cmd := New()
+	cmd.Name = "app"
+	cmd.Writer = os.Stdout
+	cmd.NewFlagSet("General").BoolP("verbose", "v", false, "Verbose output")
+	if code != DefaultUsageExitCode {
+		cmd.UsageExitCode = code
+	}
+	cmd.FailUsage("missing %s", "argument")
+}
+
+func TestFailUsage(t *testing.T) {
+	tests := []struct {
+		name     string
+		exitCode int
+	}{
+		{name: "default exit code", exitCode: DefaultUsageExitCode},
+		{name: "custom exit code", exitCode: 64},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := exec.Command(os.Args[0], "-test.run=^TestHelperFailUsage$")
+			c.Env = append(os.Environ(), "PFLAGX_TEST_FAIL_USAGE="+strconv.Itoa(tt.exitCode))
+			var stdout bytes.Buffer
+			c.Stdout = &stdout
+
+			var exitErr *exec.ExitError
+			if err := c.Run(); !errors.As(err, &exitErr) || exitErr.ExitCode() != tt.exitCode {
+				t.Fatalf("FailUsage() exited with %v, want exit code %d", err, tt.exitCode)
+			}
+
+			var out bytes.Buffer
+			cmd := newTestCommand(&out)
+			cmd.NewFlagSet("General").BoolP("verbose", "v", false, "Verbose output")
+			if want := "Error: missing argument\n" + cmd.UsageString(); stdout.String() != want {
+				t.Errorf("FailUsage() wrote %q, want %q", stdout.String(), want)
+			}
+		})
+	}
+}
+
+func TestFlagAnnotation(t *testing.T) {
+	cmd := New()
+	general := cmd.NewFlagSet("General")
+	general.Bool("verbose", false, "Verbose output")
+	if err := general.SetAnnotation("verbose", "docs", []string{"https://example.com/verbose"}); err != nil {
+		t.Fatalf("SetAnnotation() error = %v", err)
+	}
+	db := cmd.NewFlagSet("Database")
+	db.String("db-host", "localhost", "Database host")
+	if err := db.SetAnnotation("db-host", "category", []string{"network", "storage"}); err != nil {
+		t.Fatalf("SetAnnotation() error = %v", err)
+	}
+	if err := cmd.ParseSilent([]string{"--verbose"}); err != nil {
+		t.Fatalf("ParseSilent() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		flag string
+		key  string
+		want []string
+	}{
+		{name: "first group", flag: "verbose", key: "docs", want: []string{"https://example.com/verbose"}},
+		{name: "second group", flag: "db-host", key: "category", want: []string{"network", "storage"}},
+		{name: "missing key", flag: "verbose", key: "category"},
+		{name: "unknown flag", flag: "unknown", key: "docs"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cmd.FlagAnnotation(tt.flag, tt.key); !slices.Equal(got, tt.want) {
+				t.Errorf("FlagAnnotation(%q, %q) = %q, want %q", tt.flag, tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSilent(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr bool
+		errIs   error
+	}{
+		{name: "long help", args: []string{"--help"}, wantErr: true, errIs: ErrHelp},
+		{name: "short help", args: []string{"-h"}, wantErr: true, errIs: ErrHelp},
+		{name: "version", args: []string{"--version"}, wantErr: true, errIs: ErrVersion},
+		{name: "unknown flag", args: []string{"--unknown"}, wantErr: true},
+		{name: "invalid value", args: []string{"--port", "http"}, wantErr: true},
+		{name: "deprecated flag", args: []string{"--old"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := newTestCommand(&out)
+			cmd.Version = "v1.0.0"
+			fs := cmd.NewFlagSet("General")
+			fs.Int("port", 8080, "Port to listen on")
+			fs.Bool("old", false, "Old flag")
+			fs.MarkDeprecated("old", "use --port instead")
+
+			var err error
+			stdout := captureStdout(t, func() { err = cmd.ParseSilent(tt.args) })
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseSilent() error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.errIs != nil && !errors.Is(err, tt.errIs) {
+				t.Errorf("ParseSilent() error = %v, want %v", err, tt.errIs)
+			}
+			if out.Len() > 0 || len(stdout) > 0 {
+				t.Errorf("ParseSilent() wrote %q to Writer and %q to stdout, want nothing", out.String(), stdout)
+			}
+			if pflag.CommandLine.Parsed() || pflag.CommandLine.HasFlags() {
+				t.Error("ParseSilent() modified pflag.CommandLine")
+			}
+		})
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		verbose bool
+		want    []string
+	}{
+		{name: "no arguments"},
+		{name: "flags only", args: []string{"-v"}, verbose: true},
+		{name: "positionals", args: []string{"src", "dst"}, want: []string{"src", "dst"}},
+		{name: "interspersed", args: []string{"src", "-v", "dst"}, verbose: true, want: []string{"src", "dst"}},
+		{name: "terminator", args: []string{"--", "-v"}, want: []string{"-v"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := newTestCommand(&out)
+			verbose := cmd.NewFlagSet("General").BoolP("verbose", "v", false, "Verbose output")
+
+			if err := cmd.ParseArgs(tt.args); err != nil {
+				t.Fatalf("ParseArgs() error = %v", err)
+			}
+			if *verbose != tt.verbose {
+				t.Errorf("verbose = %v, want %v", *verbose, tt.verbose)
+			}
+			if !slices.Equal(cmd.Args(), tt.want) {
+				t.Errorf("Args() = %q, want %q", cmd.Args(), tt.want)
+			}
+			if cmd.NArg() != len(tt.want) {
+				t.Errorf("NArg() = %d, want %d", cmd.NArg(), len(tt.want))
+			}
+			for i, want := range tt.want {
+				if got := cmd.Arg(i); got != want {
+					t.Errorf("Arg(%d) = %q, want %q", i, got, want)
+				}
+			}
+			if got := cmd.Arg(len(tt.want)); got != "" {
+				t.Errorf("Arg(%d) = %q, want \"\"", len(tt.want), got)
+			}
+		})
+	}
+}
+
+func TestParseArgsHelp(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "long", args: []string{"--help"}},
+		{name: "short", args: []string{"-h"}},
+		{name: "after other flags", args: []string{"-v", "--help"}},
+		{name: "combined shorthands", args: []string{"-vh"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := newTestCommand(&out)
+			cmd.NewFlagSet("General").BoolP("verbose", "v", false, "Verbose output")
+
+			if err := cmd.ParseArgs(tt.args); !errors.Is(err, ErrHelp) {
+				t.Errorf("ParseArgs() error = %v, want ErrHelp", err)
+			}
+			if want := cmd.UsageString(); out.String() != want {
+				t.Errorf("ParseArgs() wrote %q, want the help %q", out.String(), want)
+			}
+		})
+	}
+}
+
+func TestGroupIndex(t *testing.T) {
+	tests := []struct {
+		name   string
+		define func(cmd *Command)
+		want   string
+	}{
+		{
+			name:   "no groups",
+			define: func(cmd *Command) {},
+		},
+		{
+			name: "counts",
+			define: func(cmd *Command) {
+				general := cmd.NewFlagSet("General Options")
+				general.Bool("verbose", false, "Verbose output")
+				general.Bool("quiet", false, "Quiet output")
+				cmd.NewFlagSet("Database Options").String("db-host", "", "Database host")
+			},
+			want: "General Options (2), Database Options (1)",
+		},
+		{
+			name: "hidden flags not counted",
+			define: func(cmd *Command) {
+				general := cmd.NewFlagSet("General Options")
+				general.Bool("verbose", false, "Verbose output")
+				general.Bool("secret", false, "Hidden secret")
+				general.MarkHidden("secret")
+			},
+			want: "General Options (1)",
+		},
+		{
+			name: "empty and hidden-only groups omitted",
+			define: func(cmd *Command) {
+				cmd.NewFlagSet("General Options").Bool("verbose", false, "Verbose output")
+				cmd.NewFlagSet("Empty").Description = "Nothing here."
+				internal := cmd.NewFlagSet("Internal")
+				internal.Bool("secret", false, "Hidden secret")
+				internal.MarkHidden("secret")
+			},
+			want: "General Options (1)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := newTestCommand(&out)
+			cmd.ShowGroupIndex = true
+			tt.define(cmd)
+
+			if got := cmd.groupIndex(nil); got != tt.want {
+				t.Errorf("groupIndex() = %q, want %q", got, tt.want)
+			}
+			if tt.want != "" && !strings.HasPrefix(cmd.UsageString(), "app\n"+tt.want+"\n\n") {
+				t.Errorf("UsageString() does not start with the index:\n%s", cmd.UsageString())
+			}
+		})
+	}
+}
+
+func TestSetOrder(t *testing.T) {
+	tests := []struct {
+		name  string
+		order []string
+		want  []string
+	}{
+		{name: "creation order", want: []string{"General", "Database", "Output"}},
+		{name: "full order", order: []string{"Output", "General", "Database"}, want: []string{"Output", "General", "Database"}},
+		{name: "others appended", order: []string{"Output"}, want: []string{"Output", "General", "Database"}},
+		{name: "unknown names ignored", order: []string{"Logging", "Database", "Output"}, want: []string{"Database", "Output", "General"}},
+		{name: "duplicates ignored", order: []string{"Output", "Output", "General"}, want: []string{"Output", "General", "Database"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := newTestCommand(&out)
+			cmd.NewFlagSet("General").BoolP("verbose", "v", false, "Verbose output")
+			cmd.NewFlagSet("Database").String("db-host", "localhost", "Database host")
+			cmd.NewFlagSet("Output").String("format", "text", "Output format")
+
+			cmd.SetOrder(tt.order...)
+
+			var got []string
+			for _, fs := range cmd.FlagSets() {
+				got = append(got, fs.Name)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("FlagSets() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetOrderAlignment(t *testing.T) {
+	var out bytes.Buffer
+	cmd := newTestCommand(&out)
+	cmd.NewFlagSet("General").BoolP("verbose", "v", false, "Verbose output")
+	cmd.NewFlagSet("Database").String("database-host", "localhost", "Database host")
+	cmd.SetOrder("Database", "General")
+
+	want := "app\n" +
+		"Database:\n" +
+		"      --database-host    Database host (default: \"localhost\")\n" +
+		"\n" +
+		"General:\n" +
+		"  -v, --verbose          Verbose output\n"
+	if got := cmd.UsageString(); got != want {
+		t.Errorf("UsageString() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestFlagSets(t *testing.T) {
+	var out bytes.Buffer
+	cmd := newTestCommand(&out)
+	general := cmd.NewFlagSet("General")
+	general.BoolP("verbose", "v", false, "Verbose output")
+	db := cmd.NewFlagSet("Database")
+	db.AddAlias("db")
+
+	flagSets := cmd.FlagSets()
+	if len(flagSets) != 2 || flagSets[0] != general || flagSets[1] != db {
+		t.Fatalf("FlagSets() = %v, want the General and Database FlagSets", flagSets)
+	}
+
+	// Reordering the copy leaves the Command alone, but the FlagSets are live.
+	flagSets[0], flagSets[1] = flagSets[1], flagSets[0]
+	flagSets[0].String("db-host", "localhost", "Database host")
+
+	want := "app\n" +
+		"General:\n" +
+		"  -v, --verbose    Verbose output\n" +
+		"\n" +
+		"Database:\n" +
+		"      --db-host    Database host (default: \"localhost\")\n"
+	if got := cmd.UsageString(); got != want {
+		t.Errorf("UsageString() =\n%s\nwant:\n%s", got, want)
+	}
+
+	tests := []struct {
+		name string
+		want *FlagSet
+	}{
+		{name: "General", want: general},
+		{name: "Database", want: db},
+		{name: "db", want: db},
+		{name: "general"},
+		{name: "Output"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cmd.LookupFlagSet(tt.name); got != tt.want {
+				t.Errorf("LookupFlagSet(%q) = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResetReparse(t *testing.T) {
+	tests := []struct {
+		name   string
+		define func(fs *FlagSet) func() string
+		args   []string
+		want   string
+	}{
+		{
+			name:   "string",
+			define: func(fs *FlagSet) func() string { p := fs.String("flag", "a", ""); return func() string { return *p } },
+			args:   []string{"--flag", "b"},
+			want:   "b",
+		},
+		{
+			name: "slice",
+			define: func(fs *FlagSet) func() string {
+				p := fs.StringSlice("flag", []string{"a"}, "")
+				return func() string { return strings.Join(*p, ",") }
+			},
+			args: []string{"--flag", "b", "--flag", "c"},
+			want: "b,c",
+		},
+		{
+			name: "count",
+			define: func(fs *FlagSet) func() string {
+				p := fs.CountP("flag", "f", "")
+				return func() string { return strconv.Itoa(*p) }
+			},
+			args: []string{"-ff"},
+			want: "2",
+		},
+		{
+			name: "map",
+			define: func(fs *FlagSet) func() string {
+				p := fs.StringToString("flag", nil, "")
+				return func() string { return fmt.Sprint(*p) }
+			},
+			args: []string{"--flag", "k=v"},
+			want: "map[k:v]",
+		},
+		{
+			name: "map with a default",
+			define: func(fs *FlagSet) func() string {
+				p := fs.StringToInt("flag", map[string]int{"a": 1}, "")
+				return func() string { return fmt.Sprint(*p) }
+			},
+			args: []string{"--flag", "b=2"},
+			want: "map[b:2]",
+		},
+		{
+			name: "array",
+			define: func(fs *FlagSet) func() string {
+				p := fs.StringArray("flag", []string{"a"}, "")
+				return func() string { return strings.Join(*p, ",") }
+			},
+			args: []string{"--flag", "b,c"},
+			want: "b,c",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := New()
+			get := tt.define(cmd.NewFlagSet("General"))
+			def := get()
+
+			for i := range 2 {
+				if err := cmd.ParseSilent(tt.args); err != nil {
+					t.Fatalf("ParseSilent() error = %v", err)
+				}
+				if got := get(); got != tt.want {
+					t.Errorf("parse %d: flag = %q, want %q", i+1, got, tt.want)
+				}
+
+				cmd.Reset()
+				if got := get(); got != def {
+					t.Errorf("after Reset: flag = %q, want the default %q", got, def)
+				}
+			}
+		})
+	}
+}
+
+func TestResetEnvAndConfig(t *testing.T) {
+	t.Setenv("APP_DB_HOST", "env.example.com")
+
+	cmd := New()
+	cmd.AutoEnv("APP")
+	fs := cmd.NewFlagSet("Database")
+	host := fs.String("db-host", "localhost", "Database host")
+	user := fs.String("db-user", "", "Database user")
+	fs.MarkRequired("db-user")
+
+	if err := cmd.LoadConfig(strings.NewReader(`{"db-user": "admin"}`), ConfigJSON); err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if err := cmd.ParseSilent(nil); err != nil {
+		t.Fatalf("ParseSilent() error = %v", err)
+	}
+	if *host != "env.example.com" || *user != "admin" {
+		t.Fatalf("db-host = %q, db-user = %q, want the environment and config values", *host, *user)
+	}
+
+	cmd.Reset()
+	if *host != "localhost" || *user != "" {
+		t.Errorf("db-host = %q, db-user = %q after Reset, want the defaults", *host, *user)
+	}
+
+	// The config is forgotten, so the required flag is missing again.
+	if err := cmd.ParseSilent(nil); err == nil {
+		t.Error("ParseSilent() after Reset error = nil, want a missing required flag")
+	}
+	if *host != "env.example.com" {
+		t.Errorf("db-host = %q after reparsing, want the environment value", *host)
+	}
+}
+
+func TestIndependentCommands(t *testing.T) {
+	tests := []struct {
+		name      string
+		argsA     []string
+		argsB     []string
+		wantPortA int
+		wantPortB int
+		wantArgsA []string
+		wantArgsB []string
+	}{
+		{
+			name:      "same flags",
+			argsA:     []string{"--port", "1"},
+			argsB:     []string{"--port", "2"},
+			wantPortA: 1,
+			wantPortB: 2,
+		},
+		{
+			name:      "one unset",
+			argsA:     []string{"--port", "1", "a"},
+			argsB:     []string{"b", "c"},
+			wantPortA: 1,
+			wantPortB: 8080,
+			wantArgsA: []string{"a"},
+			wantArgsB: []string{"b", "c"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			a, b := newTestCommand(&out), newTestCommand(&out)
+			portA := a.NewFlagSet("Server").Int("port", 8080, "Port")
+			portB := b.NewFlagSet("Server").Int("port", 8080, "Port")
+
+			if err := a.ParseArgs(tt.argsA); err != nil {
+				t.Fatalf("ParseArgs() error = %v", err)
+			}
+			if err := b.ParseArgs(tt.argsB); err != nil {
+				t.Fatalf("ParseArgs() error = %v", err)
+			}
+
+			if *portA != tt.wantPortA || *portB != tt.wantPortB {
+				t.Errorf("ports = %d and %d, want %d and %d", *portA, *portB, tt.wantPortA, tt.wantPortB)
+			}
+			if !slices.Equal(a.Args(), tt.wantArgsA) || !slices.Equal(b.Args(), tt.wantArgsB) {
+				t.Errorf("Args() = %q and %q, want %q and %q", a.Args(), b.Args(), tt.wantArgsA, tt.wantArgsB)
+			}
+			if pflag.CommandLine.Parsed() || pflag.CommandLine.HasFlags() || pflag.NArg() != 0 {
+				t.Error("ParseArgs() modified pflag.CommandLine")
+			}
+		})
+	}
+}
+
+func TestGroupSeparator(t *testing.T) {
+	groups := []string{
+		"General:\n  -v, --verbose    Verbose output\n",
+		"Database:\n      --db-host    Database host\n",
+		"Output:\n      --format     Output format\n",
+	}
+
+	tests := []struct {
+		name      string
+		separator string
+		custom    bool
+		want      string
+	}{
+		{name: "default", want: groups[0] + "\n" + groups[1] + "\n" + groups[2]},
+		{name: "none", custom: true, want: groups[0] + groups[1] + groups[2]},
+		{
+			name:      "rule",
+			separator: "\n-----\n",
+			custom:    true,
+			want:      groups[0] + "\n-----\n" + groups[1] + "\n-----\n" + groups[2],
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := newTestCommand(&out)
+			if tt.custom {
+				cmd.GroupSeparator = tt.separator
+			}
+			cmd.NewFlagSet("General").BoolP("verbose", "v", false, "Verbose output")
+			cmd.NewFlagSet("Empty")
+			cmd.NewFlagSet("Database").String("db-host", "", "Database host")
+			internal := cmd.NewFlagSet("Internal")
+			internal.Bool("secret", false, "Hidden secret")
+			internal.MarkHidden("secret")
+			cmd.NewFlagSet("Output").String("format", "", "Output format")
+
+			if got := cmd.UsageString(); got != "app\n"+tt.want {
+				t.Errorf("UsageString() = %q, want %q", got, "app\n"+tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderFlagSet(t *testing.T) {
+	tests := []struct {
+		name    string
+		group   string
+		perFlag bool
+		want    string
+		wantErr string
+	}{
+		{
+			name:  "global alignment",
+			group: "General",
+			want: "General:\n" +
+				"  -v, --verbose        Verbose output\n",
+		},
+		{
+			name:    "per flag set alignment",
+			group:   "General",
+			perFlag: true,
+			want: "General:\n" +
+				"  -v, --verbose    Verbose output\n",
+		},
+		{
+			name:  "by alias",
+			group: "db",
+			want: "Database:\n" +
+				"      --db-hostname    Database host\n",
+		},
+		{
+			name:    "unknown",
+			group:   "Network",
+			wantErr: `no such flag set "Network"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := newTestCommand(&out)
+			cmd.AlignUsagePerFlagSet = tt.perFlag
+			cmd.NewFlagSet("General").BoolP("verbose", "v", false, "Verbose output")
+			db := cmd.NewFlagSet("Database")
+			db.AddAlias("db")
+			db.String("db-hostname", "", "Database host")
+
+			got, err := cmd.RenderFlagSet(tt.group)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("RenderFlagSet() error = %v", err)
+			case tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr):
+				t.Fatalf("RenderFlagSet() error = %v, want %q", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("RenderFlagSet() =\n%s\nwant:\n%s", got, tt.want)
+			}
+			if !strings.Contains(cmd.UsageString(), got) {
+				t.Errorf("UsageString() does not contain the rendered flag set:\n%s", got)
+			}
+		})
+	}
+}
+
+func TestUsageFunc(t *testing.T) {
+	tests := []struct {
+		name      string
+		run       func(cmd *Command)
+		usageFunc bool
+		wantCalls int
+	}{
+		{
+			name:      "help",
+			run:       func(cmd *Command) { cmd.ParseArgs([]string{"--help"}) },
+			usageFunc: true,
+			wantCalls: 1,
+		},
+		{
+			name:      "usage error",
+			run:       func(cmd *Command) { cmd.UsageError("missing argument") },
+			usageFunc: true,
+			wantCalls: 1,
+		},
+		{
+			name:      "no help",
+			run:       func(cmd *Command) { cmd.ParseArgs([]string{"-v"}) },
+			usageFunc: true,
+		},
+		{
+			name: "built-in help",
+			run:  func(cmd *Command) { cmd.ParseArgs([]string{"--help"}) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := newTestCommand(&out)
+			cmd.NewFlagSet("General").BoolP("verbose", "v", false, "Verbose output")
+
+			calls := 0
+			if tt.usageFunc {
+				cmd.UsageFunc = func(c *Command) {
+					if c != cmd {
+						t.Errorf("UsageFunc called with %p, want %p", c, cmd)
+					}
+					calls++
+				}
+			}
+			help := cmd.UsageString()
+
+			tt.run(cmd)
+			if calls != tt.wantCalls {
+				t.Errorf("UsageFunc called %d times, want %d", calls, tt.wantCalls)
+			}
+			if got := strings.Contains(out.String(), help); got == tt.usageFunc {
+				t.Errorf("built-in help written = %v, want %v:\n%s", got, !tt.usageFunc, out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,424 @@
+package pflagx
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// completionCommand is the name of the subcommand added by
+// EnableCompletionCommand.
+const completionCommand = "completion"
+
+// EnableCompletionCommand adds a hidden "completion" subcommand that writes the
+// completion script of the Command for the shell given as argument, "bash",
+// "zsh" or "fish", to standard output. Parsing then returns ErrCompletion.
+// Users install the script with e.g.
+// "myapp completion bash > /etc/bash_completion.d/myapp".
+func (cmd *Command) EnableCompletionCommand() {
+	sub := New()
+	sub.Description = "Generate the completion script for bash, zsh or fish"
+	sub.Hidden = true
+	sub.Writer = cmd.Writer
+	sub.AddPositional("shell", true)
+
+	sub.action = func(silent bool) error {
+		if err := sub.ValidatePositionals(); err != nil {
+			return err
+		}
+
+		var out io.Writer = os.Stdout
+		if silent {
+			out = io.Discard
+		}
+		if err := cmd.writeCompletion(out, sub.Arg(0)); err != nil {
+			return err
+		}
+		return ErrCompletion
+	}
+
+	cmd.AddCommand(completionCommand, sub)
+}
+
+// writeCompletion writes the completion script for shell to w.
+func (cmd *Command) writeCompletion(w io.Writer, shell string) error {
+	switch shell {
+	case "bash":
+		return cmd.BashCompletion(w)
+	case "zsh":
+		return cmd.ZshCompletion(w)
+	case "fish":
+		return cmd.FishCompletion(w)
+	default:
+		return fmt.Errorf("unsupported shell %q: expected bash, zsh or fish", shell)
+	}
+}
+
+// BashCompletion writes a bash completion script for the Command to w. The
+// script completes the long and shorthand names of the flags shown in help
+// output, and file paths for the value of flags that take one, restricted to
+// the extensions given to FlagSet.MarkFileCompletion, and for each positional
+// argument added with AddPositional. Any argument completes file paths if no
+// positional argument was added. Load it with
+// "source <(myapp completion bash)".
+func (cmd *Command) BashCompletion(w io.Writer) error {
+	if cmd.Name == "" {
+		return errors.New("completion requires a command name")
+	}
+
+	var words, valueFlags, fileFlags []string
+	var extFlags []*pflag.Flag
+	for _, f := range cmd.completionFlags() {
+		names := []string{"--" + f.Name}
+		if f.Shorthand != "" {
+			names = append(names, "-"+f.Shorthand)
+		}
+
+		words = append(words, names...)
+		if f.NoOptDefVal == "" {
+			valueFlags = append(valueFlags, names...)
+			if len(fileExtensions(f)) > 0 {
+				extFlags = append(extFlags, f)
+			} else {
+				fileFlags = append(fileFlags, names...)
+			}
+		}
+	}
+
+	fn := "_" + shellIdentifier(cmd.Name) + "_completion"
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# bash completion for %s\n\n", cmd.Name)
+	fmt.Fprintf(bw, "%s()\n{\n", fn)
+	bw.WriteString("    local cur prev\n")
+	bw.WriteString("    cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	bw.WriteString("    prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n")
+
+	// Complete a file for the value of the previous flag, along with the
+	// directories leading to it if its extensions are restricted
+	if len(valueFlags) > 0 {
+		bw.WriteString("\n    case \"$prev\" in\n")
+		for _, f := range extFlags {
+			pattern := "--" + f.Name
+			if f.Shorthand != "" {
+				pattern += "|-" + f.Shorthand
+			}
+
+			compgens := []string{"$(compgen -d -- \"$cur\")"}
+			for _, ext := range fileExtensions(f) {
+				compgens = append(compgens, fmt.Sprintf("$(compgen -f -X '!*.%s' -- \"$cur\")", ext))
+			}
+
+			fmt.Fprintf(bw, "        %s)\n", pattern)
+			fmt.Fprintf(bw, "            COMPREPLY=(%s)\n", strings.Join(compgens, " "))
+			bw.WriteString("            return\n")
+			bw.WriteString("            ;;\n")
+		}
+		if len(fileFlags) > 0 {
+			fmt.Fprintf(bw, "        %s)\n", strings.Join(fileFlags, "|"))
+			bw.WriteString("            COMPREPLY=($(compgen -f -- \"$cur\"))\n")
+			bw.WriteString("            return\n")
+			bw.WriteString("            ;;\n")
+		}
+		bw.WriteString("    esac\n")
+	}
+
+	bw.WriteString("\n    if [[ \"$cur\" == -* ]]; then\n")
+	fmt.Fprintf(bw, "        COMPREPLY=($(compgen -W \"%s\" -- \"$cur\"))\n", strings.Join(words, " "))
+	bw.WriteString("        return\n")
+	bw.WriteString("    fi\n")
+
+	// Complete a file for each positional argument, counting the ones
+	// before the current word while skipping the values of flags
+	bw.WriteByte('\n')
+	if n := len(cmd.positionals); n > 0 && !cmd.hasVariadic() {
+		bw.WriteString("    local i n=0\n")
+		bw.WriteString("    for ((i = 1; i < COMP_CWORD; i++)); do\n")
+		bw.WriteString("        case \"${COMP_WORDS[i]}\" in\n")
+		if len(valueFlags) > 0 {
+			fmt.Fprintf(bw, "            %s)\n", strings.Join(valueFlags, "|"))
+			bw.WriteString("                ((i++))\n")
+			bw.WriteString("                ;;\n")
+		}
+		bw.WriteString("            -*)\n")
+		bw.WriteString("                ;;\n")
+		bw.WriteString("            *)\n")
+		bw.WriteString("                ((n++))\n")
+		bw.WriteString("                ;;\n")
+		bw.WriteString("        esac\n")
+		bw.WriteString("    done\n\n")
+		fmt.Fprintf(bw, "    if ((n < %d)); then\n", n)
+		bw.WriteString("        COMPREPLY=($(compgen -f -- \"$cur\"))\n")
+		bw.WriteString("    fi\n")
+	} else {
+		bw.WriteString("    COMPREPLY=($(compgen -f -- \"$cur\"))\n")
+	}
+
+	bw.WriteString("}\n\n")
+	fmt.Fprintf(bw, "complete -o filenames -F %s %s\n", fn, cmd.Name)
+
+	return bw.Flush()
+}
+
+// completionGroup holds the flags of a FlagSet that are completed.
+type completionGroup struct {
+	name  string
+	flags []*pflag.Flag
+}
+
+// completionGroups returns the flags shown in help output grouped by FlagSet,
+// including those inherited from the parents of a subcommand. A flag defined
+// both by a subcommand and a parent is returned once. FlagSets without such
+// flags are omitted.
+func (cmd *Command) completionGroups() []completionGroup {
+	var groups []completionGroup
+	seen := make(map[string]bool)
+
+	for _, fs := range cmd.enabledFlagSets() {
+		group := completionGroup{name: fs.Name}
+		fs.visitFlags(nil, func(f *pflag.Flag) {
+			if seen[f.Name] {
+				return
+			}
+			seen[f.Name] = true
+			group.flags = append(group.flags, f)
+		})
+
+		if len(group.flags) > 0 {
+			groups = append(groups, group)
+		}
+	}
+
+	return groups
+}
+
+// completionFlags returns the flags of every completion group, in order.
+func (cmd *Command) completionFlags() []*pflag.Flag {
+	var flags []*pflag.Flag
+	for _, group := range cmd.completionGroups() {
+		flags = append(flags, group.flags...)
+	}
+	return flags
+}
+
+// ZshCompletion writes a zsh completion script for the Command to w. Each
+// flag shown in help output is described by the first line of its usage,
+// and the flags are grouped by FlagSet. The values of the flags complete file
+// paths, limited to their extensions for the flags marked with
+// FlagSet.MarkFileCompletion. Each positional argument added with
+// AddPositional completes file paths, as does any argument if none was
+// added. Save it as "_myapp" in a directory of $fpath, or load it with
+// "source <(myapp completion zsh)".
+func (cmd *Command) ZshCompletion(w io.Writer) error {
+	if cmd.Name == "" {
+		return errors.New("completion requires a command name")
+	}
+
+	fn := "_" + shellIdentifier(cmd.Name)
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "#compdef %s\n\n", cmd.Name)
+	fmt.Fprintf(bw, "%s() {\n", fn)
+	bw.WriteString("  local -a args\n")
+
+	for _, group := range cmd.completionGroups() {
+		bw.WriteByte('\n')
+		if group.name != "" {
+			fmt.Fprintf(bw, "  # %s\n", firstLine(group.name))
+		}
+
+		bw.WriteString("  args+=(\n")
+		for _, f := range group.flags {
+			fmt.Fprintf(bw, "    %s\n", zshArgumentSpec(f))
+		}
+		bw.WriteString("  )\n")
+	}
+
+	bw.WriteString("\n  # Positional arguments\n")
+	bw.WriteString("  args+=(\n")
+	for _, spec := range cmd.zshPositionalSpecs() {
+		fmt.Fprintf(bw, "    %s\n", spec)
+	}
+	bw.WriteString("  )\n")
+
+	bw.WriteString("\n  _arguments -s \"${args[@]}\"\n")
+	bw.WriteString("}\n\n")
+	fmt.Fprintf(bw, "if [ \"$funcstack[1]\" = \"%s\" ]; then\n", fn)
+	fmt.Fprintf(bw, "  %s \"$@\"\n", fn)
+	bw.WriteString("else\n")
+	fmt.Fprintf(bw, "  compdef %s %s\n", fn, cmd.Name)
+	bw.WriteString("fi\n")
+
+	return bw.Flush()
+}
+
+// zshArgumentSpec returns the _arguments spec of f, e.g.
+// "'(-c --config)'{-c,--config}'[Config file]:config:_files -g \"*.(yaml|yml)\"'"
+// for a flag marked with FlagSet.MarkFileCompletion.
+func zshArgumentSpec(f *pflag.Flag) string {
+	spec := "[" + zshEscape(firstLine(f.Usage)) + "]"
+	if f.NoOptDefVal == "" {
+		spec += ":" + zshEscape(f.Name) + ":_files"
+		if exts := f.Annotations[annotationFileExt]; len(exts) > 0 {
+			spec += ` -g "*.(` + strings.Join(exts, "|") + `)"`
+		}
+	}
+
+	if f.Shorthand == "" {
+		return "'--" + f.Name + spec + "'"
+	}
+
+	return fmt.Sprintf("'(-%s --%s)'{-%s,--%s}'%s'", f.Shorthand, f.Name, f.Shorthand, f.Name, spec)
+}
+
+// zshPositionalSpecs returns the _arguments specs of the positional
+// arguments, e.g. "':source:_files'" for a required argument and
+// "'::filter:_files'" for an optional one, or a spec completing files for
+// every argument if there is no positional argument.
+func (cmd *Command) zshPositionalSpecs() []string {
+	if len(cmd.positionals) == 0 {
+		return []string{"'*:file:_files'"}
+	}
+
+	specs := make([]string, len(cmd.positionals))
+	for i, p := range cmd.positionals {
+		name := zshEscape(p.name)
+		switch {
+		case p.variadic:
+			specs[i] = "'*:" + name + ":_files'"
+		case p.required:
+			specs[i] = "':" + name + ":_files'"
+		default:
+			specs[i] = "'::" + name + ":_files'"
+		}
+	}
+	return specs
+}
+
+// zshEscape escapes s for use in the description or message of an
+// _arguments spec enclosed in single quotes.
+func zshEscape(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`).Replace(s)
+	return strings.ReplaceAll(s, "'", `'\''`)
+}
+
+// FishCompletion writes a fish completion script for the Command to w, with
+// one "complete" line per flag shown in help output, described by the first
+// line of its usage. The value of the flags marked with
+// FlagSet.MarkFileCompletion completes file paths with their extensions.
+// Arguments complete file paths, but only up to the number of positional
+// arguments added with AddPositional, if any, unless the last one is
+// variadic. Save it as "myapp.fish" in ~/.config/fish/completions, or load
+// it with "myapp completion fish | source".
+func (cmd *Command) FishCompletion(w io.Writer) error {
+	if cmd.Name == "" {
+		return errors.New("completion requires a command name")
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# fish completion for %s\n\n", cmd.Name)
+
+	// Stop completing files once every positional argument is given
+	if n := len(cmd.positionals); n > 0 && !cmd.hasVariadic() {
+		fn := "__fish_" + shellIdentifier(cmd.Name) + "_positionals"
+		cmd.writeFishPositionalsFunc(bw, fn)
+		fmt.Fprintf(bw, "complete -c %s -n 'test (%s) -ge %d' -f\n\n", cmd.Name, fn, n)
+	}
+
+	for _, f := range cmd.completionFlags() {
+		fmt.Fprintf(bw, "complete -c %s -l %s", cmd.Name, f.Name)
+		if f.Shorthand != "" {
+			fmt.Fprintf(bw, " -s %s", f.Shorthand)
+		}
+		if f.NoOptDefVal == "" {
+			bw.WriteString(" -r")
+			if exts := fileExtensions(f); len(exts) > 0 {
+				suffixes := make([]string, len(exts))
+				for i, ext := range exts {
+					suffixes[i] = "__fish_complete_suffix ." + ext
+				}
+				fmt.Fprintf(bw, " -f -a %s", fishQuote("("+strings.Join(suffixes, "; ")+")"))
+			} else if _, ok := f.Annotations[annotationFileExt]; ok {
+				bw.WriteString(" -F")
+			}
+		}
+		if usage := firstLine(f.Usage); usage != "" {
+			fmt.Fprintf(bw, " -d %s", fishQuote(usage))
+		}
+		bw.WriteByte('\n')
+	}
+
+	return bw.Flush()
+}
+
+// writeFishPositionalsFunc writes the fish function fn, which prints the
+// number of positional arguments on the command line before the cursor,
+// skipping the values of flags.
+func (cmd *Command) writeFishPositionalsFunc(bw *bufio.Writer, fn string) {
+	var valueFlags []string
+	for _, f := range cmd.completionFlags() {
+		if f.NoOptDefVal == "" {
+			valueFlags = append(valueFlags, "--"+f.Name)
+			if f.Shorthand != "" {
+				valueFlags = append(valueFlags, "-"+f.Shorthand)
+			}
+		}
+	}
+
+	fmt.Fprintf(bw, "function %s\n", fn)
+	bw.WriteString("    set -l words (commandline -opc)\n")
+	bw.WriteString("    set -e words[1]\n")
+	bw.WriteString("    set -l n 0\n")
+	bw.WriteString("    set -l skip 0\n")
+	bw.WriteString("    for w in $words\n")
+	bw.WriteString("        if test $skip -eq 1\n")
+	bw.WriteString("            set skip 0\n")
+	bw.WriteString("            continue\n")
+	bw.WriteString("        end\n")
+	bw.WriteString("        switch $w\n")
+	if len(valueFlags) > 0 {
+		fmt.Fprintf(bw, "            case %s\n", strings.Join(valueFlags, " "))
+		bw.WriteString("                set skip 1\n")
+	}
+	bw.WriteString("            case '-*'\n")
+	bw.WriteString("            case '*'\n")
+	bw.WriteString("                set n (math $n + 1)\n")
+	bw.WriteString("        end\n")
+	bw.WriteString("    end\n")
+	bw.WriteString("    echo $n\n")
+	bw.WriteString("end\n")
+}
+
+// fileExtensions returns the extensions of the files completed for the value
+// of f, as set with FlagSet.MarkFileCompletion.
+func fileExtensions(f *pflag.Flag) []string {
+	return f.Annotations[annotationFileExt]
+}
+
+// fishQuote returns s enclosed in single quotes, escaping backslashes and
+// single quotes the way fish expects.
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
+
+// firstLine returns the first line of s.
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
+
+// shellIdentifier returns s with every character that is not valid in a
+// shell function name replaced by an underscore.
+func shellIdentifier(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, s)
+}
@@ -0,0 +1,246 @@
+package pflagx
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// checkGolden compares got with the content of the golden file name in
+// testdata, or writes it there if the -update flag is set.
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output does not match %s:\n%s", path, got)
+	}
+}
+
+// completionShells maps each supported shell to its completion generator.
+var completionShells = []struct {
+	shell string
+	write func(cmd *Command, w io.Writer) error
+}{
+	{"bash", (*Command).BashCompletion},
+	{"zsh", (*Command).ZshCompletion},
+	{"fish", (*Command).FishCompletion},
+}
+
+func TestCompletionPositionals(t *testing.T) {
+	for _, sh := range completionShells {
+		t.Run(sh.shell, func(t *testing.T) {
+			cmd := New()
+			cmd.Name = "app"
+			cmd.NewFlagSet("General").BoolP("verbose", "v", false, "Verbose output")
+			cmd.AddPositional("source", true)
+			cmd.AddPositional("destination", false)
+
+			var out bytes.Buffer
+			if err := sh.write(cmd, &out); err != nil {
+				t.Fatalf("completion error = %v", err)
+			}
+			checkGolden(t, "positionals."+sh.shell, out.Bytes())
+		})
+	}
+}
+
+// captureStdout returns what fn writes to os.Stdout.
+func captureStdout(t *testing.T, fn func()) []byte {
+	t.Helper()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	done := make(chan []byte)
+	go func() {
+		b, _ := io.ReadAll(r)
+		done <- b
+	}()
+
+	fn()
+	w.Close()
+	return <-done
+}
+
+func TestCompletionCommand(t *testing.T) {
+	for _, sh := range completionShells {
+		t.Run(sh.shell, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := newTestCommand(&out)
+			fs := cmd.NewFlagSet("General")
+			fs.String("config", "", "Config file")
+			fs.MarkRequired("config")
+			cmd.EnableCompletionCommand()
+
+			var err error
+			got := captureStdout(t, func() {
+				err = cmd.ParseArgs([]string{"completion", sh.shell})
+			})
+			if !errors.Is(err, ErrCompletion) {
+				t.Fatalf("ParseArgs() error = %v, want ErrCompletion", err)
+			}
+
+			var want bytes.Buffer
+			if err := sh.write(cmd, &want); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want.Bytes()) {
+				t.Errorf("completion command wrote:\n%s\nwant:\n%s", got, want.Bytes())
+			}
+			if out.Len() > 0 {
+				t.Errorf("unexpected output:\n%s", out.String())
+			}
+		})
+	}
+}
+
+func TestCompletionCommandErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"unsupported shell", []string{"completion", "powershell"}},
+		{"missing shell", []string{"completion"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := newTestCommand(&out)
+			cmd.EnableCompletionCommand()
+
+			var err error
+			got := captureStdout(t, func() {
+				err = cmd.ParseArgs(tt.args)
+			})
+			if err == nil || errors.Is(err, ErrCompletion) {
+				t.Errorf("ParseArgs() error = %v, want an error", err)
+			}
+			if len(got) > 0 {
+				t.Errorf("unexpected completion script:\n%s", got)
+			}
+		})
+	}
+}
+
+func TestCompletionFileExtensions(t *testing.T) {
+	for _, sh := range completionShells {
+		t.Run(sh.shell, func(t *testing.T) {
+			cmd := New()
+			cmd.Name = "app"
+			fs := cmd.NewFlagSet("General")
+			fs.StringP("config", "c", "", "Config file")
+			fs.String("log", "", "Log file")
+			if err := fs.MarkFileCompletion("config", "yaml", ".yml"); err != nil {
+				t.Fatalf("MarkFileCompletion() error = %v", err)
+			}
+			if err := fs.MarkFileCompletion("log"); err != nil {
+				t.Fatalf("MarkFileCompletion() error = %v", err)
+			}
+
+			var out bytes.Buffer
+			if err := sh.write(cmd, &out); err != nil {
+				t.Fatalf("completion error = %v", err)
+			}
+			checkGolden(t, "file_extensions."+sh.shell, out.Bytes())
+		})
+	}
+}
+
+// newCompletionCommand returns a Command with a hyphenated name and the
+// kinds of flags the completion scripts handle differently.
+func newCompletionCommand() *Command {
+	cmd := New()
+	cmd.Name = "my-app"
+
+	general := cmd.NewFlagSet("General Options")
+	general.BoolP("verbose", "v", false, "Verbose output")
+	general.StringP("output", "o", "", "Output file.\nDefaults to standard output")
+	general.String("secret", "", "Hidden secret")
+	general.MarkHidden("secret")
+
+	database := cmd.NewFlagSet("Database Options")
+	database.String("db-host", "localhost", "Database server's hostname")
+	database.IntP("db-port", "p", 5432, "Database port")
+
+	return cmd
+}
+
+func TestBashCompletion(t *testing.T) {
+	var out bytes.Buffer
+	if err := newCompletionCommand().BashCompletion(&out); err != nil {
+		t.Fatalf("BashCompletion() error = %v", err)
+	}
+	checkGolden(t, "flags.bash", out.Bytes())
+
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash is not installed")
+	}
+	check := exec.Command(bash, "-n")
+	check.Stdin = &out
+	if b, err := check.CombinedOutput(); err != nil {
+		t.Errorf("bash -n error = %v:\n%s", err, b)
+	}
+}
+
+func TestZshCompletion(t *testing.T) {
+	var out bytes.Buffer
+	if err := newCompletionCommand().ZshCompletion(&out); err != nil {
+		t.Fatalf("ZshCompletion() error = %v", err)
+	}
+	checkGolden(t, "flags.zsh", out.Bytes())
+
+	zsh, err := exec.LookPath("zsh")
+	if err != nil {
+		t.Skip("zsh is not installed")
+	}
+	check := exec.Command(zsh, "-n")
+	check.Stdin = &out
+	if b, err := check.CombinedOutput(); err != nil {
+		t.Errorf("zsh -n error = %v:\n%s", err, b)
+	}
+}
+
+func TestFishCompletion(t *testing.T) {
+	var out bytes.Buffer
+	if err := newCompletionCommand().FishCompletion(&out); err != nil {
+		t.Fatalf("FishCompletion() error = %v", err)
+	}
+	checkGolden(t, "flags.fish", out.Bytes())
+
+	fish, err := exec.LookPath("fish")
+	if err != nil {
+		t.Skip("fish is not installed")
+	}
+	check := exec.Command(fish, "--no-execute")
+	check.Stdin = &out
+	if b, err := check.CombinedOutput(); err != nil {
+		t.Errorf("fish --no-execute error = %v:\n%s", err, b)
+	}
+}
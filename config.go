@@ -0,0 +1,181 @@
+package pflagx
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFormat is the format of a config file read by LoadConfig.
+type ConfigFormat int
+
+const (
+	// ConfigJSON is the JSON format.
+	ConfigJSON ConfigFormat = iota
+
+	// ConfigYAML is the YAML format.
+	ConfigYAML
+
+	// ConfigTOML is the TOML format.
+	ConfigTOML
+)
+
+// String returns the name of the format.
+func (f ConfigFormat) String() string {
+	switch f {
+	case ConfigJSON:
+		return "json"
+	case ConfigYAML:
+		return "yaml"
+	case ConfigTOML:
+		return "toml"
+	default:
+		return fmt.Sprintf("ConfigFormat(%d)", int(f))
+	}
+}
+
+// LoadConfig sets the flags that were not set on the command line or from
+// the environment from the config read from r. The top-level keys of the
+// config are matched against the long names of the flags, or the keys
+// returned by ConfigKeyFunc, and the values are set through the Value of each
+// flag, the same way as on the command line. Lists set slice flags. Values
+// are resolved in order of precedence: the command line, then the
+// environment, then the config, then the default value of the flag, whether
+// LoadConfig is called before or after parsing.
+//
+// Unknown keys are reported with an *UnknownConfigKeysError once all the
+// known keys are applied. If StrictConfig is set, the error is returned
+// before any flag is set.
+func (cmd *Command) LoadConfig(r io.Reader, format ConfigFormat) error {
+	values, err := decodeConfig(r, format)
+	if err != nil {
+		return err
+	}
+
+	keys := slices.Sorted(maps.Keys(values))
+	flags := cmd.configFlagKeys()
+
+	var unknown []string
+	for _, key := range keys {
+		if flags[key] == nil {
+			unknown = append(unknown, key)
+		}
+	}
+
+	if len(unknown) > 0 && cmd.StrictConfig {
+		return &UnknownConfigKeysError{Keys: unknown}
+	}
+
+	if cmd.configFlags == nil {
+		cmd.configFlags = make(map[string]bool)
+	}
+
+	for _, key := range keys {
+		f := flags[key]
+		if f == nil || f.Changed || cmd.envFlags[f.Name] || values[key] == nil {
+			continue
+		}
+
+		if err := setFromConfig(f, values[key]); err != nil {
+			return fmt.Errorf("invalid value for --%s in config: %w", f.Name, err)
+		}
+		cmd.configFlags[f.Name] = true
+	}
+
+	if len(unknown) > 0 {
+		return &UnknownConfigKeysError{Keys: unknown}
+	}
+
+	return nil
+}
+
+// configFlagKeys returns the flags of the Command by config key. A flag
+// defined in several FlagSets is the one of the first FlagSet.
+func (cmd *Command) configFlagKeys() map[string]*pflag.Flag {
+	flags := make(map[string]*pflag.Flag)
+	for _, fs := range cmd.flagSets {
+		fs.VisitAll(func(f *pflag.Flag) {
+			key := f.Name
+			if cmd.ConfigKeyFunc != nil {
+				key = cmd.ConfigKeyFunc(f.Name)
+			}
+
+			if _, ok := flags[key]; !ok {
+				flags[key] = f
+			}
+		})
+	}
+	return flags
+}
+
+// decodeConfig decodes the config read from r into a map of top-level keys.
+func decodeConfig(r io.Reader, format ConfigFormat) (map[string]any, error) {
+	values := make(map[string]any)
+
+	var err error
+	switch format {
+	case ConfigJSON:
+		dec := json.NewDecoder(r)
+		dec.UseNumber()
+		err = dec.Decode(&values)
+	case ConfigYAML:
+		err = yaml.NewDecoder(r).Decode(&values)
+		if err == io.EOF {
+			err = nil
+		}
+	case ConfigTOML:
+		_, err = toml.NewDecoder(r).Decode(&values)
+	default:
+		return nil, fmt.Errorf("unsupported config format %v", format)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("parsing %v config: %w", format, err)
+	}
+
+	return values, nil
+}
+
+// setFromConfig sets the value of f from a decoded config value.
+func setFromConfig(f *pflag.Flag, value any) error {
+	list, isList := value.([]any)
+	if !isList {
+		s, err := configString(value)
+		if err != nil {
+			return err
+		}
+		return f.Value.Set(s)
+	}
+
+	elems := make([]string, len(list))
+	for i, v := range list {
+		s, err := configString(v)
+		if err != nil {
+			return err
+		}
+		elems[i] = s
+	}
+
+	joined := strings.Join(elems, ",")
+	if ok, err := setSlice(f.Value, elems, joined); ok {
+		return err
+	}
+	return f.Value.Set(joined)
+}
+
+// configString returns the string form of a scalar config value.
+func configString(value any) (string, error) {
+	switch value.(type) {
+	case map[string]any, []any, nil:
+		return "", fmt.Errorf("unsupported value %v", value)
+	default:
+		return fmt.Sprint(value), nil
+	}
+}
@@ -0,0 +1,184 @@
+package pflagx
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestConfigKeyFunc(t *testing.T) {
+	snakeCase := func(name string) string { return strings.ReplaceAll(name, "-", "_") }
+
+	tests := []struct {
+		name        string
+		keyFunc     func(string) string
+		config      string
+		wantHost    string
+		wantPort    int
+		wantUnknown []string
+	}{
+		{
+			name:     "snake case keys",
+			keyFunc:  snakeCase,
+			config:   `{"db_host": "db.example.com", "db_port": 6543}`,
+			wantHost: "db.example.com",
+			wantPort: 6543,
+		},
+		{
+			name:     "identity by default",
+			config:   `{"db-host": "db.example.com", "db-port": 6543}`,
+			wantHost: "db.example.com",
+			wantPort: 6543,
+		},
+		{
+			name:        "kebab case keys with snake case mapping",
+			keyFunc:     snakeCase,
+			config:      `{"db-host": "db.example.com"}`,
+			wantHost:    "localhost",
+			wantPort:    5432,
+			wantUnknown: []string{"db-host"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := New()
+			cmd.ConfigKeyFunc = tt.keyFunc
+			fs := cmd.NewFlagSet("Database")
+			host := fs.String("db-host", "localhost", "Database host")
+			port := fs.Int("db-port", 5432, "Database port")
+
+			err := cmd.LoadConfig(strings.NewReader(tt.config), ConfigJSON)
+
+			var unknownErr *UnknownConfigKeysError
+			switch {
+			case tt.wantUnknown == nil && err != nil:
+				t.Fatalf("LoadConfig() error = %v", err)
+			case tt.wantUnknown != nil && !errors.As(err, &unknownErr):
+				t.Fatalf("LoadConfig() error = %v, want *UnknownConfigKeysError", err)
+			case tt.wantUnknown != nil && !slices.Equal(unknownErr.Keys, tt.wantUnknown):
+				t.Errorf("unknown keys = %q, want %q", unknownErr.Keys, tt.wantUnknown)
+			}
+
+			if *host != tt.wantHost || *port != tt.wantPort {
+				t.Errorf("db-host = %q, db-port = %d, want %q and %d", *host, *port, tt.wantHost, tt.wantPort)
+			}
+		})
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		format      ConfigFormat
+		config      string
+		args        []string
+		strict      bool
+		wantHost    string
+		wantPort    int
+		wantTags    []string
+		wantUnknown []string
+		wantErr     bool
+	}{
+		{
+			name:     "json",
+			format:   ConfigJSON,
+			config:   `{"db-host": "db.example.com", "db-port": 6543, "tags": ["a", "b"]}`,
+			wantHost: "db.example.com",
+			wantPort: 6543,
+			wantTags: []string{"a", "b"},
+		},
+		{
+			name:     "yaml",
+			format:   ConfigYAML,
+			config:   "db-host: db.example.com\ndb-port: 6543\ntags:\n  - a\n  - b\n",
+			wantHost: "db.example.com",
+			wantPort: 6543,
+			wantTags: []string{"a", "b"},
+		},
+		{
+			name:     "toml",
+			format:   ConfigTOML,
+			config:   "db-host = \"db.example.com\"\ndb-port = 6543\ntags = [\"a\", \"b\"]\n",
+			wantHost: "db.example.com",
+			wantPort: 6543,
+			wantTags: []string{"a", "b"},
+		},
+		{
+			name:     "command line takes precedence",
+			format:   ConfigJSON,
+			config:   `{"db-host": "db.example.com", "db-port": 6543}`,
+			args:     []string{"--db-host", "cli.example.com"},
+			wantHost: "cli.example.com",
+			wantPort: 6543,
+		},
+		{
+			name:        "unknown keys",
+			format:      ConfigJSON,
+			config:      `{"db-host": "db.example.com", "db-name": "app", "color": true}`,
+			wantHost:    "db.example.com",
+			wantPort:    5432,
+			wantUnknown: []string{"color", "db-name"},
+		},
+		{
+			name:        "unknown keys in strict mode",
+			format:      ConfigJSON,
+			config:      `{"db-host": "db.example.com", "db-name": "app"}`,
+			strict:      true,
+			wantHost:    "localhost",
+			wantPort:    5432,
+			wantUnknown: []string{"db-name"},
+		},
+		{
+			name:    "invalid value",
+			format:  ConfigJSON,
+			config:  `{"db-port": "http"}`,
+			wantErr: true,
+		},
+		{
+			name:    "malformed",
+			format:  ConfigYAML,
+			config:  "db-host: [",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := New()
+			cmd.StrictConfig = tt.strict
+			fs := cmd.NewFlagSet("Database")
+			host := fs.String("db-host", "localhost", "Database host")
+			port := fs.Int("db-port", 5432, "Database port")
+			tags := fs.StringSlice("tags", nil, "Tags")
+
+			if err := cmd.ParseSilent(tt.args); err != nil {
+				t.Fatalf("ParseSilent() error = %v", err)
+			}
+			err := cmd.LoadConfig(strings.NewReader(tt.config), tt.format)
+
+			var unknownErr *UnknownConfigKeysError
+			switch {
+			case tt.wantErr:
+				if err == nil || errors.As(err, &unknownErr) {
+					t.Errorf("LoadConfig() error = %v, want a parsing error", err)
+				}
+				return
+			case tt.wantUnknown == nil && err != nil:
+				t.Fatalf("LoadConfig() error = %v", err)
+			case tt.wantUnknown != nil && !errors.As(err, &unknownErr):
+				t.Fatalf("LoadConfig() error = %v, want *UnknownConfigKeysError", err)
+			case tt.wantUnknown != nil && !slices.Equal(unknownErr.Keys, tt.wantUnknown):
+				t.Errorf("unknown keys = %q, want %q", unknownErr.Keys, tt.wantUnknown)
+			}
+
+			if *host != tt.wantHost || *port != tt.wantPort {
+				t.Errorf("db-host = %q, db-port = %d, want %q and %d", *host, *port, tt.wantHost, tt.wantPort)
+			}
+			if !slices.Equal(*tags, tt.wantTags) {
+				t.Errorf("tags = %q, want %q", *tags, tt.wantTags)
+			}
+		})
+	}
+}
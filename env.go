@@ -0,0 +1,142 @@
+package pflagx
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// BindEnv binds the named flag to the environment variable envVar. When the
+// flag is not set on the command line, its value is read from envVar.
+func (s *FlagSet) BindEnv(flagName, envVar string) error {
+	return s.SetAnnotation(flagName, annotationEnv, []string{envVar})
+}
+
+// ExcludeEnv opts the named flag out of the automatic binding enabled with
+// Command.AutoEnv. A variable bound explicitly with BindEnv is still read.
+func (s *FlagSet) ExcludeEnv(flagName string) error {
+	return s.SetAnnotation(flagName, annotationNoEnv, []string{"true"})
+}
+
+// AutoEnv binds every flag to the environment variable named after prefix
+// and the flag in SCREAMING_SNAKE_CASE, so --db-host reads MYAPP_DB_HOST with
+// the prefix "MYAPP". Values are resolved in order of precedence: the command
+// line, then the environment, then a config loaded with LoadConfig, then the
+// default value of the flag. The flags of subcommands without a prefix of
+// their own are bound with this prefix.
+func (cmd *Command) AutoEnv(prefix string) {
+	cmd.EnvPrefix = prefix
+}
+
+// envVar returns the name of the environment variable bound to the flag:
+// the one set with BindEnv, or the one derived from prefix if prefix is not
+// empty. It returns an empty string if the flag is not bound.
+func envVar(f *pflag.Flag, prefix string) string {
+	if name := flagAnnotation(f, annotationEnv); name != "" {
+		return name
+	}
+	if prefix != "" && flagAnnotation(f, annotationNoEnv) == "" {
+		return envName(prefix, f.Name)
+	}
+	return ""
+}
+
+// envName derives the name of the environment variable of a flag by
+// uppercasing it, replacing runs of dashes with a single underscore, and
+// prefixing it with prefix and an underscore. Leading and trailing dashes
+// are dropped, so "--db-host" with the prefix "MYAPP" gives "MYAPP_DB_HOST".
+func envName(prefix, flag string) string {
+	parts := strings.FieldsFunc(flag, func(r rune) bool {
+		return r == '-' || r == '_'
+	})
+
+	name := strings.ToUpper(strings.Join(parts, "_"))
+	if prefix == "" {
+		return name
+	}
+	return strings.TrimSuffix(prefix, "_") + "_" + name
+}
+
+// envPrefixOf returns the prefix of the environment variables of the flags
+// of fs: the EnvPrefix of the Command of the parent chain that fs belongs to,
+// or of its closest parent that has one.
+func (cmd *Command) envPrefixOf(fs *FlagSet) string {
+	owner := cmd
+	for c := cmd; c != nil; c = c.parent {
+		if slices.Contains(c.flagSets, fs) {
+			owner = c
+			break
+		}
+	}
+
+	for c := owner; c != nil; c = c.parent {
+		if c.EnvPrefix != "" {
+			return c.EnvPrefix
+		}
+	}
+	return ""
+}
+
+// applyEnv sets the flags that were not set on the command line from their
+// environment variables, and records the flags set this way. The flags
+// inherited from the parents of a subcommand are included. The values of
+// slice flags are split on EnvSliceSeparator.
+func (cmd *Command) applyEnv() error {
+	cmd.envFlags = make(map[string]bool)
+
+	for _, fs := range cmd.enabledFlagSets() {
+		prefix := cmd.envPrefixOf(fs)
+
+		var err error
+		fs.VisitAll(func(f *pflag.Flag) {
+			if err != nil || f.Changed {
+				return
+			}
+
+			name := envVar(f, prefix)
+			if name == "" {
+				return
+			}
+
+			value, ok := os.LookupEnv(name)
+			if !ok {
+				return
+			}
+
+			if setErr := cmd.setFromEnv(f, value); setErr != nil {
+				err = fmt.Errorf("invalid argument %q for --%s from environment variable %s: %v "+
+					"(the command line takes precedence over the environment, which takes precedence over defaults)",
+					value, f.Name, name, setErr)
+				return
+			}
+			cmd.envFlags[f.Name] = true
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// setFromEnv sets the value of f from an environment variable. Slice values
+// are replaced by the elements of value split on EnvSliceSeparator.
+func (cmd *Command) setFromEnv(f *pflag.Flag, value string) error {
+	if cmd.EnvSliceSeparator == "" {
+		return f.Value.Set(value)
+	}
+
+	var elems []string
+	if value != "" {
+		elems = strings.Split(value, cmd.EnvSliceSeparator)
+	}
+
+	if ok, err := setSlice(f.Value, elems, value); ok {
+		return err
+	}
+	return f.Value.Set(value)
+}
@@ -0,0 +1,264 @@
+package pflagx
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestEnvName(t *testing.T) {
+	tests := []struct {
+		prefix string
+		flag   string
+		want   string
+	}{
+		{prefix: "MYAPP", flag: "db-host", want: "MYAPP_DB_HOST"},
+		{prefix: "", flag: "db-host", want: "DB_HOST"},
+		{prefix: "MYAPP_", flag: "db-host", want: "MYAPP_DB_HOST"},
+		{prefix: "MYAPP", flag: "-db-host-", want: "MYAPP_DB_HOST"},
+		{prefix: "MYAPP", flag: "db--host", want: "MYAPP_DB_HOST"},
+		{prefix: "MYAPP", flag: "DB-Host", want: "MYAPP_DB_HOST"},
+		{prefix: "MYAPP", flag: "db_host", want: "MYAPP_DB_HOST"},
+		{prefix: "MYAPP", flag: "ipv6", want: "MYAPP_IPV6"},
+		{prefix: "MYAPP", flag: "3d-mode", want: "MYAPP_3D_MODE"},
+		{prefix: "MYAPP", flag: "42", want: "MYAPP_42"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.prefix+"/"+tt.flag, func(t *testing.T) {
+			if got := envName(tt.prefix, tt.flag); got != tt.want {
+				t.Errorf("envName(%q, %q) = %q, want %q", tt.prefix, tt.flag, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBindEnv(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		args     []string
+		required bool
+		wantHost string
+		wantPort int
+		wantErr  bool
+	}{
+		{
+			name:     "defaults",
+			wantHost: "localhost",
+			wantPort: 5432,
+		},
+		{
+			name:     "bound variable",
+			env:      map[string]string{"DATABASE_HOST": "db.example.com"},
+			wantHost: "db.example.com",
+			wantPort: 5432,
+		},
+		{
+			name:     "prefixed variable",
+			env:      map[string]string{"PFLAGX_TEST_DB_PORT": "6543"},
+			wantHost: "localhost",
+			wantPort: 6543,
+		},
+		{
+			name:     "command line takes precedence",
+			env:      map[string]string{"DATABASE_HOST": "db.example.com", "PFLAGX_TEST_DB_PORT": "6543"},
+			args:     []string{"--db-host", "cli.example.com"},
+			wantHost: "cli.example.com",
+			wantPort: 6543,
+		},
+		{
+			name:     "required flag from the environment",
+			env:      map[string]string{"DATABASE_HOST": "db.example.com"},
+			required: true,
+			wantHost: "db.example.com",
+			wantPort: 5432,
+		},
+		{
+			name:     "required flag missing",
+			required: true,
+			wantErr:  true,
+		},
+		{
+			name:    "invalid value",
+			env:     map[string]string{"PFLAGX_TEST_DB_PORT": "http"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			cmd := New()
+			cmd.EnvPrefix = "PFLAGX_TEST"
+			fs := cmd.NewFlagSet("Database")
+			host := fs.String("db-host", "localhost", "Database host")
+			port := fs.Int("db-port", 5432, "Database port")
+			if err := fs.BindEnv("db-host", "DATABASE_HOST"); err != nil {
+				t.Fatalf("BindEnv() error = %v", err)
+			}
+			if tt.required {
+				fs.MarkRequired("db-host")
+			}
+
+			err := cmd.ParseSilent(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSilent() error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if *host != tt.wantHost || *port != tt.wantPort {
+				t.Errorf("db-host = %q, db-port = %d, want %q, %d", *host, *port, tt.wantHost, tt.wantPort)
+			}
+		})
+	}
+}
+
+func TestShowEnvInUsage(t *testing.T) {
+	cmd := New()
+	cmd.EnvPrefix = "MYAPP"
+	cmd.ShowEnvInUsage = true
+	fs := cmd.NewFlagSet("Database")
+	fs.String("db-host", "localhost", "Database host")
+	fs.String("db-user", "", "Database user")
+	fs.BindEnv("db-user", "PGUSER")
+
+	want := "Database:\n" +
+		"      --db-host    Database host [env: MYAPP_DB_HOST] (default: \"localhost\")\n" +
+		"      --db-user    Database user [env: PGUSER]\n"
+
+	got, err := cmd.RenderFlagSet("Database")
+	if err != nil {
+		t.Fatalf("RenderFlagSet() error = %v", err)
+	}
+	if got != want {
+		t.Errorf("RenderFlagSet() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestAutoEnv(t *testing.T) {
+	tests := []struct {
+		name      string
+		env       map[string]string
+		args      []string
+		separator string
+		wantHost  string
+		wantTags  []string
+	}{
+		{
+			name:     "defaults",
+			wantHost: "localhost",
+		},
+		{
+			name:     "environment",
+			env:      map[string]string{"MYAPP_DB_HOST": "db.example.com"},
+			wantHost: "db.example.com",
+		},
+		{
+			name:     "command line takes precedence",
+			env:      map[string]string{"MYAPP_DB_HOST": "db.example.com"},
+			args:     []string{"--db-host", "cli.example.com"},
+			wantHost: "cli.example.com",
+		},
+		{
+			name:     "excluded flag",
+			env:      map[string]string{"MYAPP_TOKEN": "secret"},
+			wantHost: "localhost",
+		},
+		{
+			name:     "slice split on the default separator",
+			env:      map[string]string{"MYAPP_TAGS": "a,b,c"},
+			wantHost: "localhost",
+			wantTags: []string{"a", "b", "c"},
+		},
+		{
+			name:      "slice split on a custom separator",
+			env:       map[string]string{"MYAPP_TAGS": "a,b;c"},
+			separator: ";",
+			wantHost:  "localhost",
+			wantTags:  []string{"a,b", "c"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			cmd := New()
+			cmd.AutoEnv("MYAPP")
+			if tt.separator != "" {
+				cmd.EnvSliceSeparator = tt.separator
+			}
+			fs := cmd.NewFlagSet("General")
+			host := fs.String("db-host", "localhost", "Database host")
+			tags := fs.StringSlice("tags", nil, "Tags")
+			token := fs.String("token", "", "API token")
+			if err := fs.ExcludeEnv("token"); err != nil {
+				t.Fatalf("ExcludeEnv() error = %v", err)
+			}
+
+			if err := cmd.ParseSilent(tt.args); err != nil {
+				t.Fatalf("ParseSilent() error = %v", err)
+			}
+			if *host != tt.wantHost {
+				t.Errorf("db-host = %q, want %q", *host, tt.wantHost)
+			}
+			if !slices.Equal(*tags, tt.wantTags) {
+				t.Errorf("tags = %q, want %q", *tags, tt.wantTags)
+			}
+			if *token != "" {
+				t.Errorf("token = %q, want it unset from the environment", *token)
+			}
+		})
+	}
+}
+
+func TestShowEnvInUsageWrapping(t *testing.T) {
+	tests := []struct {
+		name  string
+		width int
+		usage string
+		want  string
+	}{
+		{
+			name:  "multi-line usage",
+			usage: "Database host.\nUses a local socket if empty",
+			want: "      --db-host    Database host.\n" +
+				"                   Uses a local socket if empty [env: DB_ADDR]\n" +
+				"      --db-port    Database port (default: 5432)\n",
+		},
+		{
+			name:  "wrapped usage",
+			width: 50,
+			usage: "Hostname of the database server to connect to",
+			want: "      --db-host    Hostname of the database server\n" +
+				"                   to connect to [env: DB_ADDR]\n" +
+				"      --db-port    Database port (default: 5432)\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := New()
+			cmd.Width = tt.width
+			cmd.ShowEnvInUsage = true
+			fs := cmd.NewFlagSet("Database")
+			fs.String("db-host", "", tt.usage)
+			fs.Int("db-port", 5432, "Database port")
+			fs.BindEnv("db-host", "DB_ADDR")
+
+			got, err := cmd.RenderFlagSet("Database")
+			if err != nil {
+				t.Fatalf("RenderFlagSet() error = %v", err)
+			}
+			if want := "Database:\n" + tt.want; got != want {
+				t.Errorf("RenderFlagSet() =\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package pflagx
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// ErrHelp is the error returned if the help flag is given but not defined.
+var ErrHelp = pflag.ErrHelp
+
+// ErrVersion is the error returned after printing the version if the
+// --version flag added by the Command is given.
+var ErrVersion = errors.New("pflagx: version requested")
+
+// ErrCompletion is the error returned after writing a completion script with
+// the subcommand added by Command.EnableCompletionCommand.
+var ErrCompletion = errors.New("pflagx: completion script written")
+
+// InvalidValueError is returned when a flag value is not one of the allowed
+// values.
+type InvalidValueError struct {
+	// Flag is the name of the flag.
+	Flag string
+
+	// Value is the invalid value.
+	Value string
+
+	// Allowed lists the allowed values.
+	Allowed []string
+
+	// Suggestion is the allowed value closest to Value, or an empty string
+	// if none is close enough.
+	Suggestion string
+}
+
+// Error returns the error message, suggesting the closest allowed value if any.
+func (e *InvalidValueError) Error() string {
+	if e.Suggestion != "" {
+		return fmt.Sprintf("invalid value %q for --%s; did you mean %q?", e.Value, e.Flag, e.Suggestion)
+	}
+
+	quoted := make([]string, len(e.Allowed))
+	for i, a := range e.Allowed {
+		quoted[i] = strconv.Quote(a)
+	}
+
+	return fmt.Sprintf("invalid value %q for --%s: must be one of %s", e.Value, e.Flag, strings.Join(quoted, ", "))
+}
+
+// newInvalidValueError returns an InvalidValueError with the closest allowed
+// value as suggestion.
+func newInvalidValueError(flag, value string, allowed []string) *InvalidValueError {
+	return &InvalidValueError{
+		Flag:       flag,
+		Value:      value,
+		Allowed:    allowed,
+		Suggestion: suggest(value, allowed),
+	}
+}
+
+// UnknownConfigKeysError is returned by LoadConfig when the config contains
+// keys that do not match any flag.
+type UnknownConfigKeysError struct {
+	// Keys lists the unknown keys in alphabetical order.
+	Keys []string
+}
+
+// Error returns the error message listing the unknown keys.
+func (e *UnknownConfigKeysError) Error() string {
+	return fmt.Sprintf("unknown keys in config: %s", strings.Join(e.Keys, ", "))
+}
@@ -1,11 +1,37 @@
 package pflagx
 
 import (
+	"fmt"
+	"slices"
+	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/spf13/pflag"
 )
 
+// Annotation keys used to store pflagx metadata on flags.
+const (
+	annotationUnit     = "pflagx_unit"
+	annotationExample  = "pflagx_example"
+	annotationRequired = "pflagx_required"
+	annotationEnv      = "pflagx_env"
+	annotationNoEnv    = "pflagx_noenv"
+	annotationFileExt  = "pflagx_file_ext"
+)
+
+// minLeaderGap is the minimum gap between the usage text and a default value
+// right-aligned with leaders: a space, three leaders and a space.
+const minLeaderGap = 5
+
+// maxLeaderEdge is the widest column at which default values are
+// right-aligned with leaders.
+const maxLeaderEdge = 80
+
+// specialChars lists the characters that cause a default value to be quoted.
+const specialChars = "\"'`$&|;<>()*?#"
+
 // FlagSet represents a group of flags with additional formatting options.
 // It extends spf13/pflag.FlagSet with descriptive text and layout controls.
 type FlagSet struct {
@@ -14,23 +40,142 @@ type FlagSet struct {
 	// Name is the title of the flag group.
 	Name string
 
+	// HideName determines if the Name header is omitted from help output.
+	// The Name is still used to identify the group.
+	HideName bool
+
 	// Description appears below the Name and before any flags.
 	Description string
 
 	// Footer appears after all flags in the group.
 	Footer string
 
+	// FooterIndent overrides Indentation for the Footer when not nil, e.g.
+	// to write an example block at column 0.
+	FooterIndent *int
+
+	// FooterPreformatted determines if the Footer is written as is instead
+	// of being wrapped to the width of the help output, e.g. for examples.
+	FooterPreformatted bool
+
+	// FooterIsCode determines if the Footer holds shell code, such as
+	// examples. It is written as preformatted text in help output, and its
+	// fenced code block is marked as "sh" in Markdown output.
+	FooterIsCode bool
+
 	// Indentation is the number of spaces to indent all content in the group.
 	Indentation int
 
 	// Padding is the minimum number of spaces between flag names and their usage text.
 	Padding int
 
+	// ContinuationIndent is the number of spaces, possibly negative, added to
+	// the indentation of usage text continuation lines relative to the
+	// usage column.
+	ContinuationIndent int
+
 	// SortFlags determines if flags should be sorted alphabetically.
 	SortFlags bool
 
+	// HideFlags determines if the flags are omitted from help output
+	// while the Name, Description and Footer are still shown.
+	HideFlags bool
+
+	// AlignUsage overrides Command.AlignUsagePerFlagSet for this group when
+	// not nil. If true, the usage text is aligned with the other globally
+	// aligned groups; if false, it is aligned on this group's flags only.
+	AlignUsage *bool
+
+	// QuoteStringDefaults determines if the default values of string flags
+	// are wrapped in quotes. Defaults containing whitespace or special
+	// characters are always quoted.
+	QuoteStringDefaults bool
+
+	// ShowEnvInUsage determines if the environment variable bound to
+	// a flag is shown after its usage text, e.g. "[env: MYAPP_DB_HOST]".
+	ShowEnvInUsage bool
+
+	// RequiredSuffix is appended to the usage text of required flags,
+	// before the default value. An empty suffix disables the marker.
+	RequiredSuffix string
+
+	// ShowExamples determines if the example values set with SetExample
+	// are shown after the usage text.
+	ShowExamples bool
+
+	// DefaultLeader, when not empty, right-aligns the default values and
+	// fills the gap between the usage text and the default with it,
+	// e.g. "." for dotted leaders.
+	DefaultLeader string
+
+	// ShowTypes determines if the type of each flag is shown in its own
+	// column between the flag name and the usage text.
+	ShowTypes bool
+
+	// ShowBoolTypes determines if the "bool" type is shown when ShowTypes
+	// is set. Boolean flags conventionally show no type.
+	ShowBoolTypes bool
+
+	// HideDefaults determines if the default values of the flags are
+	// omitted from help output.
+	HideDefaults bool
+
+	// DefaultFormatter, when not nil, returns the default value annotation
+	// of a flag shown after its usage text, such as "[default: 8080]", or an
+	// empty string to omit it. When nil, defaults are shown as
+	// "(default: value)" when they are not zero values.
+	DefaultFormatter func(f *pflag.Flag) string
+
+	// Enabled determines if the flags of the group are parsed. The flags of a
+	// disabled group are rejected as unknown flags.
+	Enabled bool
+
+	// aliases holds alternate names used to look up the group.
+	aliases []string
+
+	// cmd is the Command the group was added to, or nil.
+	cmd *Command
+
 	// computedPadding is the total padding for aligning usage text.
 	computedPadding int
+
+	// typeColumn is the column at which the types start when ShowTypes is set.
+	typeColumn int
+
+	// envPrefix is the prefix used to derive the environment variable
+	// of the flags that are not explicitly bound.
+	envPrefix string
+
+	// colors holds the colors of the help output, which are all empty
+	// when color is disabled.
+	colors Colors
+
+	// extraFlags are shown after the flags of the FlagSet without belonging
+	// to it, such as the --version flag added by the Command.
+	extraFlags []*pflag.Flag
+
+	// exclusive maps the name of each flag to the visible flags it is
+	// mutually exclusive with, as declared on the Command showing it.
+	exclusive map[string][]string
+
+	// computedWidth is the number of columns at which usage text is wrapped,
+	// or zero to disable wrapping.
+	computedWidth int
+}
+
+// NewFlagSet creates a new standalone FlagSet with the given name. It can
+// be attached to a Command with Command.AddFlagSet, which applies the
+// Command settings to the ones left at their zero value.
+func NewFlagSet(name string) *FlagSet {
+	fs := &FlagSet{
+		FlagSet: pflag.NewFlagSet(name, pflag.ContinueOnError),
+
+		Name: name,
+
+		Enabled: true,
+	}
+
+	return fs
 }
 
 // ToString returns the formatted string representation of the FlagSet,
@@ -38,112 +183,572 @@ type FlagSet struct {
 // Unfortunately, we can't use String as a method name because that would
 // override the pflag.FlagSet String method.
 func (s *FlagSet) ToString() string {
+	return s.toString(nil)
+}
+
+// toString returns the formatted string representation of the FlagSet.
+// If match is not nil, only the flags for which it returns true are shown.
+func (s *FlagSet) toString(match func(*pflag.Flag) bool) string {
 	sb := strings.Builder{}
 
 	// Indentation
 	indentation := strings.Repeat(" ", s.Indentation)
 
 	// Name of the FlagSet
-	if s.Name != "" {
-		sb.WriteString(s.Name)
-		sb.WriteString(":\n")
+	if s.Name != "" && !s.HideName {
+		sb.WriteString(paint(s.Name+":", s.colors.GroupTitle))
+		sb.WriteByte('\n')
 	}
 
 	// Description of the FlagSet
 	if s.Description != "" {
-		writeWithPrefix(&sb, s.Description, indentation)
+		writeWithPrefix(&sb, s.Description, indentation, s.colors.Description, s.computedWidth)
 	}
 
-	// Parse all the flags
-	s.FlagSet.SortFlags = s.SortFlags
-	s.FlagSet.VisitAll(func(f *pflag.Flag) {
-		// Skip flags that are hidden
-		if f.Hidden {
-			return
+	// Flags
+	sb.WriteString(s.flagsString(match))
+
+	// Footer
+	if s.Footer != "" {
+		width := s.computedWidth
+		if s.FooterPreformatted || s.FooterIsCode {
+			width = 0
 		}
+		prefix := indentation
+		if s.FooterIndent != nil {
+			prefix = strings.Repeat(" ", max(*s.FooterIndent, 0))
+		}
+		writeWithPrefix(&sb, s.Footer, prefix, "", width)
+	}
+
+	return trimTrailingSpace(sb.String())
+}
+
+// flagsString returns the formatted flags of the FlagSet, one per line,
+// without the name, description and footer of the group. If match is not
+// nil, only the flags for which it returns true are shown.
+func (s *FlagSet) flagsString(match func(*pflag.Flag) bool) string {
+	sb := strings.Builder{}
+	indentation := strings.Repeat(" ", s.Indentation)
 
-		flagBuilder := strings.Builder{}
+	// Column at which the types start, one space after the longest name
+	nameLen, _ := s.columnWidths(match)
+	s.typeColumn = s.Indentation + 4 + 2 + nameLen + 1
 
-		// Indentation
-		flagBuilder.WriteString(indentation)
+	// Format all the flags
+	var rows []flagRow
+	s.visitFlags(match, func(f *pflag.Flag) {
+		rows = append(rows, s.formatFlag(f, indentation))
+	})
 
-		// Shorthand flag
-		if f.Shorthand != "" {
-			flagBuilder.WriteByte('-')
-			flagBuilder.WriteString(f.Shorthand)
-			flagBuilder.WriteString(", ")
-		} else {
-			flagBuilder.WriteString("    ")
-		}
+	// Column at which the default values end when using leaders
+	leaderEdge := s.leaderEdge(rows)
 
-		// Long flag
-		flagBuilder.WriteString("--")
-		flagBuilder.WriteString(f.Name)
-
-		// Padding between flag name and usage
-		repeat := max(s.computedPadding-flagBuilder.Len(), 0)
-		flagBuilder.WriteString(strings.Repeat(" ", repeat))
-
-		// Usage
-		if f.Usage != "" {
-			addPadding := false
-			for line := range strings.SplitSeq(f.Usage, "\n") {
-				if addPadding {
-					flagBuilder.WriteByte('\n')
-					flagBuilder.WriteString(strings.Repeat(" ", s.computedPadding))
-				}
-				flagBuilder.WriteString(line)
-				addPadding = true
-			}
+	for _, row := range rows {
+		s.writeRow(&sb, row, leaderEdge)
+		sb.WriteByte('\n')
+	}
 
-			// Default value
-			if shouldPrintDefault(f) {
-				quotes := false
-				switch f.Value.Type() {
-				case "string":
-					quotes = true
-				}
-
-				flagBuilder.WriteString(" (default: ")
-				if quotes {
-					flagBuilder.WriteByte('"')
-				}
-				flagBuilder.WriteString(f.DefValue)
-				if quotes {
-					flagBuilder.WriteByte('"')
-				}
-				flagBuilder.WriteByte(')')
+	return sb.String()
+}
+
+// flagRow holds the formatted text of a flag and its default value annotation,
+// which are kept apart so that the default can be aligned. The plain text has
+// the default wrapped along with the usage text instead, for rows that are
+// too long to align their default.
+type flagRow struct {
+	text  string
+	def   string
+	plain string
+}
+
+// formatFlag returns the formatted flag with its usage text.
+func (s *FlagSet) formatFlag(f *pflag.Flag, indentation string) flagRow {
+	flagBuilder := strings.Builder{}
+
+	// Indentation
+	flagBuilder.WriteString(indentation)
+
+	// Shorthand flag
+	if f.Shorthand != "" && f.ShorthandDeprecated == "" {
+		flagBuilder.WriteString(paint("-"+f.Shorthand, s.colors.Shorthand))
+		flagBuilder.WriteString(", ")
+	} else {
+		flagBuilder.WriteString("    ")
+	}
+
+	// Long flag
+	flagBuilder.WriteString(paint("--"+f.Name, s.colors.FlagName))
+
+	// Type, aligned in its own column
+	if typ := s.flagType(f); typ != "" {
+		column := visibleLength(flagBuilder.String())
+		flagBuilder.WriteString(strings.Repeat(" ", max(s.typeColumn-column, 1)))
+		flagBuilder.WriteString(typ)
+	}
+
+	// Padding between flag name and usage, keeping at least Padding
+	// spaces when the name overflows the usage column
+	column := visibleLength(flagBuilder.String())
+	repeat := max(s.computedPadding-column, s.Padding)
+	flagBuilder.WriteString(strings.Repeat(" ", repeat))
+	column += repeat
+
+	// Usage
+	if f.Usage == "" {
+		return flagRow{text: flagBuilder.String()}
+	}
+
+	// Example value, environment variable, required marker, mutually
+	// exclusive flags and default value, in that order, kept attached to
+	// the last word of the usage text when wrapping. Defaults aligned with
+	// leaders are written separately.
+	var tail string
+	if example := flagAnnotation(f, annotationExample); s.ShowExamples && example != "" {
+		tail += " (e.g. " + example + ")"
+	}
+	if name := envVar(f, s.envPrefix); s.ShowEnvInUsage && name != "" {
+		tail += " [env: " + name + "]"
+	}
+	if isRequired(f) {
+		tail += s.RequiredSuffix
+	}
+	if others := s.exclusive[f.Name]; len(others) > 0 {
+		tail += " (mutually exclusive with --" + strings.Join(others, ", --") + ")"
+	}
+
+	def := paint(s.defaultString(f), s.colors.Default)
+	if def == "" {
+		return flagRow{text: s.wrapUsage(flagBuilder.String(), f.Usage, tail, column)}
+	}
+
+	plain := s.wrapUsage(flagBuilder.String(), f.Usage, tail+" "+def, column)
+	if s.DefaultLeader == "" {
+		return flagRow{text: plain}
+	}
+
+	return flagRow{
+		text:  s.wrapUsage(flagBuilder.String(), f.Usage, tail, column),
+		def:   def,
+		plain: plain,
+	}
+}
+
+// wrapUsage returns head followed by the usage text starting at column, with
+// tail kept attached to its last word. Each line of the usage text is
+// wrapped, and continuation lines are indented to the usage column offset by
+// ContinuationIndent.
+func (s *FlagSet) wrapUsage(head, usage, tail string, column int) string {
+	sb := strings.Builder{}
+	sb.WriteString(head)
+
+	continuation := max(s.computedPadding+s.ContinuationIndent, 0)
+	segments := strings.Split(usage, "\n")
+	addPadding := false
+	for i, segment := range segments {
+		segmentTail := ""
+		if i == len(segments)-1 {
+			segmentTail = tail
+		}
+
+		for _, line := range wrapLine(segment, segmentTail, s.computedWidth, column, continuation) {
+			if addPadding {
+				sb.WriteByte('\n')
+				sb.WriteString(strings.Repeat(" ", continuation))
 			}
+			sb.WriteString(line)
+			addPadding = true
 		}
+		column = continuation
+	}
 
-		// Write the flag string to the main string builder
-		sb.WriteString(flagBuilder.String())
-		sb.WriteByte('\n')
-	})
+	return sb.String()
+}
 
-	// Footer
-	if s.Footer != "" {
-		writeWithPrefix(&sb, s.Footer, indentation)
+// defaultValue returns the default value of the flag as shown in help
+// output, without the annotation added by defaultString: the output of
+// DefaultFormatter if set, else the default value itself. An empty string is
+// returned if defaultString shows no default.
+func (s *FlagSet) defaultValue(f *pflag.Flag) string {
+	def := s.defaultString(f)
+	if def == "" || s.DefaultFormatter != nil {
+		return def
+	}
+	return f.DefValue
+}
+
+// defaultString returns the default value annotation of the flag,
+// or an empty string if the default should not be shown.
+func (s *FlagSet) defaultString(f *pflag.Flag) string {
+	if s.HideDefaults {
+		return ""
+	}
+	if s.DefaultFormatter != nil {
+		return s.DefaultFormatter(f)
 	}
 
+	if !shouldPrintDefault(f) {
+		return ""
+	}
+
+	sb := strings.Builder{}
+	quotes := shouldQuoteDefault(f, s.QuoteStringDefaults)
+
+	sb.WriteString("(default: ")
+	if quotes {
+		sb.WriteByte('"')
+	}
+	sb.WriteString(f.DefValue)
+	if quotes {
+		sb.WriteByte('"')
+	}
+	if unit := flagAnnotation(f, annotationUnit); unit != "" {
+		sb.WriteByte(' ')
+		sb.WriteString(unit)
+	}
+	sb.WriteByte(')')
+
 	return sb.String()
 }
 
-// maxNameLength returns the length of the longest flag name in the FlagSet.
-func (s *FlagSet) maxNameLength() int {
-	maxLen := 0
-	s.FlagSet.VisitAll(func(f *pflag.Flag) {
+// writeRow writes the row with its default value annotation. If edge is not
+// zero and the row leaves enough room, the annotation is right-aligned to
+// edge and the gap is filled with the DefaultLeader. Otherwise the plain text
+// of the row is written, with the annotation wrapped along with the usage.
+func (s *FlagSet) writeRow(sb *strings.Builder, row flagRow, edge int) {
+	if row.def == "" {
+		sb.WriteString(row.text)
+		return
+	}
+
+	gap := edge - lastLineLength(row.text) - visibleLength(row.def)
+	leaderLen := utf8.RuneCountInString(s.DefaultLeader)
+	if edge == 0 || leaderLen == 0 || gap < minLeaderGap {
+		sb.WriteString(row.plain)
+		return
+	}
+
+	// Fill the gap between the surrounding spaces with whole leaders
+	fill := gap - 2
+	sb.WriteString(row.text)
+	sb.WriteByte(' ')
+	sb.WriteString(strings.Repeat(" ", fill%leaderLen))
+	sb.WriteString(strings.Repeat(s.DefaultLeader, fill/leaderLen))
+	sb.WriteByte(' ')
+	sb.WriteString(row.def)
+}
+
+// leaderEdge returns the column at which default values end when they are
+// right-aligned with leaders, or zero if DefaultLeader is not set. Rows too
+// long to fit within maxLeaderEdge, or the wrapping width, do not push the
+// edge and are written without leaders.
+func (s *FlagSet) leaderEdge(rows []flagRow) int {
+	if s.DefaultLeader == "" {
+		return 0
+	}
+
+	limit := maxLeaderEdge
+	if s.computedWidth > 0 {
+		limit = min(limit, s.computedWidth)
+	}
+
+	var edge int
+	for _, row := range rows {
+		if row.def == "" {
+			continue
+		}
+
+		width := lastLineLength(row.text) + minLeaderGap + visibleLength(row.def)
+		if width <= limit {
+			edge = max(edge, width)
+		}
+	}
+
+	return edge
+}
+
+// hasName returns whether name is the Name or one of the aliases of the
+// FlagSet.
+func (s *FlagSet) hasName(name string) bool {
+	return s.Name == name || slices.Contains(s.aliases, name)
+}
+
+// visitFlags calls fn for each flag shown in help output, in display order.
+// Nothing is visited if HideFlags is set. Hidden flags are skipped, as are
+// flags for which match returns false when match is not nil.
+func (s *FlagSet) visitFlags(match func(*pflag.Flag) bool, fn func(*pflag.Flag)) {
+	if s.HideFlags {
+		return
+	}
+
+	s.visitAllFlags(func(f *pflag.Flag) {
 		if f.Hidden {
 			return
 		}
-		maxLen = max(maxLen, len(f.Name))
+		if match != nil && !match(f) {
+			return
+		}
+		fn(f)
 	})
-	return maxLen
+}
+
+// visitAllFlags calls fn for each flag of the FlagSet, sorted if SortFlags
+// is set, followed by its extra flags.
+func (s *FlagSet) visitAllFlags(fn func(*pflag.Flag)) {
+	s.FlagSet.SortFlags = s.SortFlags
+	s.FlagSet.VisitAll(fn)
+	for _, f := range s.extraFlags {
+		fn(f)
+	}
+}
+
+// SetUnit sets the unit shown after the default value of the named flag
+// in help output, e.g. "(default: 30 s)".
+func (s *FlagSet) SetUnit(name, unit string) error {
+	return s.SetAnnotation(name, annotationUnit, []string{unit})
+}
+
+// AddAlias adds an alternate name used to look up the FlagSet, such as
+// "db" for "Database Options". Aliases are not shown in help output.
+func (s *FlagSet) AddAlias(name string) {
+	s.aliases = append(s.aliases, name)
+}
+
+// MarkRequired marks the named flag as required. Parsing fails if a required
+// flag is not set, and required flags are marked as such in help output.
+func (s *FlagSet) MarkRequired(name string) error {
+	return s.SetAnnotation(name, annotationRequired, []string{"true"})
+}
+
+// OnSet registers fn to be called with the raw value each time the named
+// flag is set while parsing, including from the environment or a config file.
+// It is called once per occurrence of repeatable flags such as slices and
+// counts, and not at all for flags left unset.
+func (s *FlagSet) OnSet(name string, fn func(value string)) error {
+	f := s.Lookup(name)
+	if f == nil {
+		return fmt.Errorf("no such flag --%s", name)
+	}
+
+	wrapValue(f, func(v pflag.Value) pflag.Value {
+		return &onSetValue{Value: v, fn: fn}
+	})
+	return nil
+}
+
+// Alias adds alias as an alternate long name of the canonical flag. Both names
+// set the same value and are marked as changed when either is given on the
+// command line. The alias is hidden from help output. Callbacks and
+// validators registered on either name apply to both. It returns an error if
+// the canonical flag does not exist or a flag named alias already exists in
+// the FlagSet or, once it is added to a Command, in any FlagSet of the
+// Command or its parents.
+func (s *FlagSet) Alias(canonical, alias string) error {
+	f := s.Lookup(canonical)
+	if f == nil {
+		return fmt.Errorf("no such flag --%s", canonical)
+	}
+	if s.flagExists(alias) {
+		return fmt.Errorf("flag --%s already exists", alias)
+	}
+
+	v, ok := f.Value.(*aliasedValue)
+	if !ok {
+		v = &aliasedValue{Value: f.Value, flags: []*pflag.Flag{f}}
+		f.Value = v
+	}
+
+	a := &pflag.Flag{
+		Name:        alias,
+		Usage:       f.Usage,
+		Value:       v,
+		DefValue:    f.DefValue,
+		NoOptDefVal: f.NoOptDefVal,
+		Hidden:      true,
+	}
+	s.AddFlag(a)
+	v.flags = append(v.flags, a)
+
+	return nil
+}
+
+// SetValidator registers fn to validate each value given to the named flag
+// while parsing, after it is parsed into its type. An error returned by fn
+// fails the parse. The default value is not validated. Validators registered
+// on the same flag are called in order of registration.
+func (s *FlagSet) SetValidator(name string, fn func(value string) error) error {
+	f := s.Lookup(name)
+	if f == nil {
+		return fmt.Errorf("no such flag --%s", name)
+	}
+
+	wrapValue(f, func(v pflag.Value) pflag.Value {
+		return &validatedValue{Value: v, fn: fn}
+	})
+	return nil
+}
+
+// flagExists reports whether a flag named name exists in the FlagSet or, once
+// it is added to a Command, in any FlagSet of the Command or its parents.
+func (s *FlagSet) flagExists(name string) bool {
+	if s.Lookup(name) != nil {
+		return true
+	}
+
+	for c := s.cmd; c != nil; c = c.parent {
+		if c.lookupFlag(name) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// IntBase defines an int flag that accepts hexadecimal (0x), octal (0o or 0),
+// and binary (0b) values in addition to decimal ones.
+func (s *FlagSet) IntBase(name, shorthand string, def int, usage string) *int {
+	p := new(int)
+	s.VarP(newIntBaseValue(def, p), name, shorthand, usage)
+	return p
+}
+
+// ExistingFile defines a string flag whose value must be the path to an
+// existing regular file. An empty value means unset and is not checked.
+func (s *FlagSet) ExistingFile(name, def, usage string) *string {
+	return s.ExistingFileP(name, "", def, usage)
+}
+
+// ExistingFileP is like ExistingFile, but accepts a shorthand letter.
+func (s *FlagSet) ExistingFileP(name, shorthand, def, usage string) *string {
+	p := new(string)
+	s.VarP(newPathValue(def, p, false), name, shorthand, usage)
+	return p
+}
+
+// ExistingDir defines a string flag whose value must be the path to an
+// existing directory. An empty value means unset and is not checked.
+func (s *FlagSet) ExistingDir(name, def, usage string) *string {
+	return s.ExistingDirP(name, "", def, usage)
+}
+
+// ExistingDirP is like ExistingDir, but accepts a shorthand letter.
+func (s *FlagSet) ExistingDirP(name, shorthand, def, usage string) *string {
+	p := new(string)
+	s.VarP(newPathValue(def, p, true), name, shorthand, usage)
+	return p
+}
+
+// MarkFileCompletion marks the named flag as taking a file path, so that the
+// completion scripts only complete the files with the given extensions, such
+// as "yaml" or ".yml", along with directories. Every file is completed if no
+// extension is given.
+func (s *FlagSet) MarkFileCompletion(name string, extensions ...string) error {
+	exts := make([]string, len(extensions))
+	for i, ext := range extensions {
+		exts[i] = strings.TrimPrefix(ext, ".")
+	}
+	return s.SetAnnotation(name, annotationFileExt, exts)
+}
+
+// SetExample sets an example value for the named flag, shown after its
+// usage text in help output when ShowExamples is enabled.
+func (s *FlagSet) SetExample(name, example string) error {
+	return s.SetAnnotation(name, annotationExample, []string{example})
+}
+
+// Ratio defines a float64 flag accepting any value, whose number of decimals
+// shown in help output can be set with SetDecimals.
+func (s *FlagSet) Ratio(name string, def float64, usage string) *float64 {
+	p := new(float64)
+	s.Var(newRatioValue(def, p), name, usage)
+	return p
+}
+
+// RatioRange defines a float64 flag that only accepts values between min
+// and max inclusively, e.g. 0 and 1 for a ratio.
+func (s *FlagSet) RatioRange(name string, def, min, max float64, usage string) *float64 {
+	p := new(float64)
+	v := newRatioValue(def, p)
+	v.min, v.max, v.bounded = min, max, true
+	s.Var(v, name, usage)
+	return p
+}
+
+// SetDecimals sets the number of decimals used to format the value of the
+// named Ratio or RatioRange flag, including its default in help output.
+// A negative number uses the smallest number of decimals necessary.
+func (s *FlagSet) SetDecimals(name string, decimals int) error {
+	f := s.Lookup(name)
+	if f == nil {
+		return fmt.Errorf("no such flag --%s", name)
+	}
+
+	v, ok := baseValue(f.Value).(*ratioValue)
+	if !ok {
+		return fmt.Errorf("flag --%s is not a ratio flag", name)
+	}
+
+	v.decimals = decimals
+	if def, err := strconv.ParseFloat(f.DefValue, 64); err == nil {
+		f.DefValue = v.format(def)
+	}
+
+	return nil
+}
+
+// VerbosityCount defines a count flag named "verbose" with the given
+// shorthand, such that -v, -vv and -vvv select increasingly verbose levels.
+// The returned function yields levels[n] after parsing, where n is the
+// number of times the flag was given, clamped to the last level.
+func (s *FlagSet) VerbosityCount(shorthand string, levels []string, usage string) func() string {
+	count := s.CountP("verbose", shorthand, usage)
+
+	return func() string {
+		if len(levels) == 0 {
+			return ""
+		}
+		return levels[min(max(*count, 0), len(levels)-1)]
+	}
+}
+
+// maxNameLength returns the length of the longest flag name in the FlagSet,
+// followed by a space and the longest type when ShowTypes is set. If match
+// is not nil, only the flags for which it returns true are considered.
+func (s *FlagSet) maxNameLength(match func(*pflag.Flag) bool) int {
+	nameLen, typeLen := s.columnWidths(match)
+	if typeLen > 0 {
+		return nameLen + 1 + typeLen
+	}
+	return nameLen
+}
+
+// columnWidths returns the length of the longest flag name and of the
+// longest type shown in the FlagSet. If match is not nil, only the flags
+// for which it returns true are considered.
+func (s *FlagSet) columnWidths(match func(*pflag.Flag) bool) (nameLen, typeLen int) {
+	s.visitFlags(match, func(f *pflag.Flag) {
+		nameLen = max(nameLen, len(f.Name))
+		typeLen = max(typeLen, len(s.flagType(f)))
+	})
+	return nameLen, typeLen
+}
+
+// flagType returns the type of the flag shown in help output, or an empty
+// string if types are not shown.
+func (s *FlagSet) flagType(f *pflag.Flag) string {
+	if !s.ShowTypes {
+		return ""
+	}
+
+	typ := f.Value.Type()
+	if typ == "bool" && !s.ShowBoolTypes {
+		return ""
+	}
+	return typ
 }
 
 // computePadding computes and sets the total padding needed to align usage text.
-// The padding is calculated as: indentation + shorthand flag +
-// double dash + maximum name length + extra padding.
+// The padding is calculated as: indentation + shorthand flag + double dash +
+// maximum name length, including the type column, + extra padding.
 func (fs *FlagSet) computePadding(maxNameLen int) {
 	padding := fs.Indentation // Length of the indentation
 	padding += 4              // Shorthand flag "-a, "
@@ -153,16 +758,41 @@ func (fs *FlagSet) computePadding(maxNameLen int) {
 	fs.computedPadding = padding
 }
 
-// writePrefixedLines writes the string s to the StringBuilder, adding the prefix string
+// writeWithPrefix writes the string s to the StringBuilder, adding the prefix string
 // to the start of each line. A newline is appended after each line, including the last one.
-func writeWithPrefix(sb *strings.Builder, s string, prefix string) {
+// Empty lines are written without the prefix to avoid trailing whitespace.
+// Each line is colored with the SGR sequence sgr if it is not empty.
+// Lines are wrapped to width columns, unless width is zero, and wrapped lines
+// keep the leading whitespace of the original line.
+func writeWithPrefix(sb *strings.Builder, s string, prefix string, sgr string, width int) {
 	// Indent each line of text
 	lines := strings.SplitSeq(s, "\n")
 	for line := range lines {
-		sb.WriteString(prefix)
-		sb.WriteString(line)
-		sb.WriteByte('\n')
+		if line == "" {
+			sb.WriteByte('\n')
+			continue
+		}
+
+		// Keep the leading whitespace of the line on each wrapped line
+		text := strings.TrimLeft(line, " \t")
+		lead := prefix + line[:len(line)-len(text)]
+
+		for _, wrapped := range wrapLine(text, "", width, len(lead), len(lead)) {
+			sb.WriteString(lead)
+			sb.WriteString(paint(wrapped, sgr))
+			sb.WriteByte('\n')
+		}
+	}
+}
+
+// trimTrailingSpace removes the whitespace at the end of every line of s,
+// such as the padding left after a flag without usage text.
+func trimTrailingSpace(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
 	}
+	return strings.Join(lines, "\n")
 }
 
 // shouldPrintDefault returns whether the default value for a flag should
@@ -171,6 +801,8 @@ func shouldPrintDefault(f *pflag.Flag) bool {
 	switch f.Value.Type() {
 	case "bool":
 		return f.DefValue == "true"
+	case "count":
+		return f.DefValue != "0"
 	case "stringSlice":
 		fallthrough
 	case "intSlice":
@@ -183,3 +815,31 @@ func shouldPrintDefault(f *pflag.Flag) bool {
 		return f.DefValue != ""
 	}
 }
+
+// shouldQuoteDefault returns whether the default value for a flag should
+// be wrapped in quotes in its usage string. String defaults are quoted if
+// quoteStrings is true, and any default containing whitespace or special
+// characters is always quoted.
+func shouldQuoteDefault(f *pflag.Flag, quoteStrings bool) bool {
+	if quoteStrings && f.Value.Type() == "string" {
+		return true
+	}
+
+	return strings.ContainsFunc(f.DefValue, func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune(specialChars, r)
+	})
+}
+
+// flagAnnotation returns the first value of the annotation key on the flag,
+// or an empty string if the annotation is not set.
+func flagAnnotation(f *pflag.Flag, key string) string {
+	if values := f.Annotations[key]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// lastLineLength returns the number of characters shown on the last line of s.
+func lastLineLength(s string) int {
+	return visibleLength(s[strings.LastIndexByte(s, '\n')+1:])
+}
@@ -0,0 +1,1200 @@
+package pflagx
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// listValue is a custom pflag.Value whose string form contains spaces.
+type listValue []string
+
+func (v *listValue) String() string     { return strings.Join(*v, " ") }
+func (v *listValue) Set(s string) error { *v = append(*v, s); return nil }
+func (v *listValue) Type() string       { return "list" }
+
+func TestDefaultQuoting(t *testing.T) {
+	tests := []struct {
+		name   string
+		define func(fs *FlagSet)
+		want   string
+	}{
+		{
+			name:   "duration",
+			define: func(fs *FlagSet) { fs.Duration("flag", 90*time.Second, "") },
+			want:   "(default: 1m30s)",
+		},
+		{
+			name:   "string with spaces",
+			define: func(fs *FlagSet) { fs.String("flag", "hello world", "") },
+			want:   `(default: "hello world")`,
+		},
+		{
+			name:   "plain int",
+			define: func(fs *FlagSet) { fs.Int("flag", 42, "") },
+			want:   "(default: 42)",
+		},
+		{
+			name:   "custom value with spaces",
+			define: func(fs *FlagSet) { fs.Var(&listValue{"a", "b"}, "flag", "") },
+			want:   `(default: "a b")`,
+		},
+		{
+			name:   "custom value with special characters",
+			define: func(fs *FlagSet) { fs.Var(&listValue{"$HOME"}, "flag", "") },
+			want:   `(default: "$HOME")`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := New().NewFlagSet("Options")
+			tt.define(fs)
+
+			if got := fs.defaultString(fs.Lookup("flag")); got != tt.want {
+				t.Errorf("defaultString() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHideName(t *testing.T) {
+	tests := []struct {
+		name     string
+		hideName bool
+		want     string
+	}{
+		{
+			name: "shown",
+			want: "Internal:\n" +
+				"  Internal options.\n" +
+				"  -v, --verbose    Verbose output\n" +
+				"  See the manual.\n",
+		},
+		{
+			name:     "hidden",
+			hideName: true,
+			want: "  Internal options.\n" +
+				"  -v, --verbose    Verbose output\n" +
+				"  See the manual.\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := New()
+			fs := cmd.NewFlagSet("Internal")
+			fs.HideName = tt.hideName
+			fs.Description = "Internal options."
+			fs.Footer = "See the manual."
+			fs.BoolP("verbose", "v", false, "Verbose output")
+
+			got, err := cmd.RenderFlagSet("Internal")
+			if err != nil {
+				t.Fatalf("RenderFlagSet() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("RenderFlagSet() =\n%s\nwant:\n%s", got, tt.want)
+			}
+			if cmd.LookupFlagSet("Internal") != fs {
+				t.Error("LookupFlagSet() does not find the FlagSet by its name")
+			}
+		})
+	}
+}
+
+func TestWriteWithPrefix(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want string
+	}{
+		{
+			name: "single line",
+			s:    "First paragraph.",
+			want: "  First paragraph.\n",
+		},
+		{
+			name: "two paragraphs",
+			s:    "First paragraph.\n\nSecond paragraph.",
+			want: "  First paragraph.\n\n  Second paragraph.\n",
+		},
+		{
+			name: "leading whitespace kept",
+			s:    "Example:\n\n  app --verbose",
+			want: "  Example:\n\n    app --verbose\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sb strings.Builder
+			writeWithPrefix(&sb, tt.s, "  ", "", 0)
+			if got := sb.String(); got != tt.want {
+				t.Errorf("writeWithPrefix() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFooterIndent(t *testing.T) {
+	tests := []struct {
+		name   string
+		custom bool
+		indent int
+		want   string
+	}{
+		{
+			name: "inherited",
+			want: "General:\n" +
+				"      --verbose    Verbose output\n" +
+				"  Examples:\n" +
+				"\n" +
+				"    app --verbose\n",
+		},
+		{
+			name:   "column 0",
+			custom: true,
+			want: "General:\n" +
+				"      --verbose    Verbose output\n" +
+				"Examples:\n" +
+				"\n" +
+				"  app --verbose\n",
+		},
+		{
+			name:   "deeper",
+			custom: true,
+			indent: 4,
+			want: "General:\n" +
+				"      --verbose    Verbose output\n" +
+				"    Examples:\n" +
+				"\n" +
+				"      app --verbose\n",
+		},
+		{
+			name:   "negative",
+			custom: true,
+			indent: -2,
+			want: "General:\n" +
+				"      --verbose    Verbose output\n" +
+				"Examples:\n" +
+				"\n" +
+				"  app --verbose\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := New().NewFlagSet("General")
+			fs.Footer = "Examples:\n\n  app --verbose"
+			if tt.custom {
+				fs.FooterIndent = &tt.indent
+			}
+			fs.Bool("verbose", false, "Verbose output")
+
+			if got := fs.ToString(); got != tt.want {
+				t.Errorf("ToString() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrimTrailingSpace(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want string
+	}{
+		{name: "empty", s: "", want: ""},
+		{name: "trailing spaces", s: "a  \nb\t\n", want: "a\nb\n"},
+		{name: "interior padding kept", s: "  --verbose    Verbose  \n", want: "  --verbose    Verbose\n"},
+		{name: "blank lines", s: "a\n   \n\nb", want: "a\n\n\nb"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := trimTrailingSpace(tt.s); got != tt.want {
+				t.Errorf("trimTrailingSpace() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNoTrailingSpace(t *testing.T) {
+	tests := []struct {
+		name   string
+		define func(fs *FlagSet)
+		want   string
+	}{
+		{
+			name: "flag without usage",
+			define: func(fs *FlagSet) {
+				fs.Bool("quiet", false, "")
+			},
+			want: "      --quiet\n" +
+				"      --verbose    Verbose output\n",
+		},
+		{
+			name: "blank line in usage",
+			define: func(fs *FlagSet) {
+				fs.Bool("quiet", false, "Suppress output.\n\nErrors are kept.")
+			},
+			want: "      --quiet      Suppress output.\n" +
+				"\n" +
+				"                   Errors are kept.\n" +
+				"      --verbose    Verbose output\n",
+		},
+		{
+			name: "blank lines in description and footer",
+			define: func(fs *FlagSet) {
+				fs.Description = "First paragraph.  \n  \nSecond paragraph."
+				fs.Footer = "Examples:\n\n  app --quiet  "
+				fs.Bool("quiet", false, "Suppress output")
+			},
+			want: "  First paragraph.\n" +
+				"\n" +
+				"  Second paragraph.\n" +
+				"      --quiet      Suppress output\n" +
+				"      --verbose    Verbose output\n" +
+				"  Examples:\n" +
+				"\n" +
+				"    app --quiet\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := newTestCommand(&out)
+			cmd.Description = "A test application.  "
+			fs := cmd.NewFlagSet("General")
+			tt.define(fs)
+			fs.Bool("verbose", false, "Verbose output")
+
+			want := "General:\n" + tt.want
+			got, err := cmd.RenderFlagSet("General")
+			if err != nil {
+				t.Fatalf("RenderFlagSet() error = %v", err)
+			}
+			if got != want {
+				t.Errorf("RenderFlagSet() = %q, want %q", got, want)
+			}
+
+			want = "app\nA test application.\n\n" + want
+			if got := cmd.UsageString(); got != want {
+				t.Errorf("UsageString() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestTwoParagraphDescription(t *testing.T) {
+	cmd := New()
+	fs := cmd.NewFlagSet("General")
+	fs.Description = "First paragraph.\n\nSecond paragraph."
+	fs.BoolP("verbose", "v", false, "Verbose output")
+
+	want := "General:\n" +
+		"  First paragraph.\n" +
+		"\n" +
+		"  Second paragraph.\n" +
+		"  -v, --verbose    Verbose output\n"
+
+	got, err := cmd.RenderFlagSet("General")
+	if err != nil {
+		t.Fatalf("RenderFlagSet() error = %v", err)
+	}
+	if got != want {
+		t.Errorf("RenderFlagSet() = %q, want %q", got, want)
+	}
+}
+
+func TestDisabledFlagSet(t *testing.T) {
+	tests := []struct {
+		name       string
+		enabled    bool
+		showHelp   bool
+		wantErr    bool
+		wantInHelp bool
+	}{
+		{name: "enabled", enabled: true, wantInHelp: true},
+		{name: "disabled", wantErr: true},
+		{name: "disabled shown in help", showHelp: true, wantErr: true, wantInHelp: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := newTestCommand(&out)
+			cmd.ShowDisabledFlagSets = tt.showHelp
+			cmd.NewFlagSet("General").BoolP("verbose", "v", false, "Verbose output")
+			db := cmd.NewFlagSet("Database")
+			db.String("db-host", "localhost", "Database host")
+			db.Enabled = tt.enabled
+
+			err := cmd.ParseSilent([]string{"--db-host", "db.example.com"})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseSilent() error = %v, want error %v", err, tt.wantErr)
+			}
+
+			help := cmd.UsageString()
+			if got := strings.Contains(help, "Database:\n      --db-host"); got != tt.wantInHelp {
+				t.Errorf("Database shown in help = %v, want %v:\n%s", got, tt.wantInHelp, help)
+			}
+		})
+	}
+}
+
+func TestVerbosityCount(t *testing.T) {
+	levels := []string{"warn", "info", "debug"}
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "none", want: "warn"},
+		{name: "single", args: []string{"-v"}, want: "info"},
+		{name: "double", args: []string{"-vv"}, want: "debug"},
+		{name: "clamped", args: []string{"-vvvv"}, want: "debug"},
+		{name: "repeated", args: []string{"-v", "--verbose"}, want: "debug"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := New()
+			level := cmd.NewFlagSet("General").VerbosityCount("v", levels, "Increase verbosity")
+
+			if err := cmd.ParseSilent(tt.args); err != nil {
+				t.Fatalf("ParseSilent() error = %v", err)
+			}
+			if got := level(); got != tt.want {
+				t.Errorf("level() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetUnit(t *testing.T) {
+	cmd := New()
+	fs := cmd.NewFlagSet("Limits")
+	fs.Int("timeout", 30, "Request timeout")
+	fs.Int("size", 512, "Maximum upload size")
+	fs.Int("retries", 3, "Number of retries")
+	fs.SetUnit("timeout", "s")
+	fs.SetUnit("size", "MB")
+
+	want := "Limits:\n" +
+		"      --timeout    Request timeout (default: 30 s)\n" +
+		"      --size       Maximum upload size (default: 512 MB)\n" +
+		"      --retries    Number of retries (default: 3)\n"
+
+	got, err := cmd.RenderFlagSet("Limits")
+	if err != nil {
+		t.Fatalf("RenderFlagSet() error = %v", err)
+	}
+	if got != want {
+		t.Errorf("RenderFlagSet() =\n%s\nwant:\n%s", got, want)
+	}
+
+	if err := fs.SetUnit("missing", "s"); err == nil {
+		t.Error("SetUnit() on a missing flag error = nil, want an error")
+	}
+}
+
+func TestQuoteStringDefaults(t *testing.T) {
+	tests := []struct {
+		name  string
+		quote bool
+		def   string
+		want  string
+	}{
+		{name: "quoted", quote: true, def: "text", want: `(default: "text")`},
+		{name: "unquoted", def: "text", want: "(default: text)"},
+		{name: "whitespace still quoted", def: "hello world", want: `(default: "hello world")`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := New()
+			cmd.QuoteStringDefaults = tt.quote
+			fs := cmd.NewFlagSet("Output")
+			fs.String("format", tt.def, "Output format")
+
+			if got := fs.defaultString(fs.Lookup("format")); got != tt.want {
+				t.Errorf("defaultString() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOnSet(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{name: "set", args: []string{"--level", "debug"}, want: []string{"debug"}},
+		{name: "unset", args: []string{"--other"}},
+		{name: "set twice", args: []string{"--level=info", "--level", "debug"}, want: []string{"info", "debug"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := New()
+			fs := cmd.NewFlagSet("General")
+			level := fs.String("level", "warn", "Log level")
+			fs.Bool("other", false, "Other flag")
+
+			var got []string
+			var seen []string
+			if err := fs.OnSet("level", func(value string) {
+				got = append(got, value)
+				seen = append(seen, *level)
+			}); err != nil {
+				t.Fatalf("OnSet() error = %v", err)
+			}
+
+			if err := cmd.ParseSilent(tt.args); err != nil {
+				t.Fatalf("ParseSilent() error = %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("OnSet callback called with %q, want %q", got, tt.want)
+			}
+			if !slices.Equal(seen, tt.want) {
+				t.Errorf("flag value in callback = %q, want %q", seen, tt.want)
+			}
+		})
+	}
+}
+
+func TestOnSetSources(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		env     string
+		config  string
+		want    []string
+		wantErr string
+	}{
+		{
+			name: "repeated slice",
+			args: []string{"--tag", "a,b", "--tag=c"},
+			want: []string{"tag=a,b", "tag=c"},
+		},
+		{
+			name: "repeated count",
+			args: []string{"-vv", "--verbose"},
+			want: []string{"verbose=+1", "verbose=+1", "verbose=+1"},
+		},
+		{
+			name: "environment",
+			env:  "debug",
+			want: []string{"level=debug"},
+		},
+		{
+			name:   "config file",
+			config: `{"level": "info"}`,
+			want:   []string{"level=info"},
+		},
+		{
+			name:    "invalid value",
+			args:    []string{"--port", "http"},
+			wantErr: `invalid argument "http" for "--port" flag: strconv.ParseInt: parsing "http": invalid syntax`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("APP_LEVEL", tt.env)
+
+			cmd := New()
+			fs := cmd.NewFlagSet("General")
+			fs.StringSlice("tag", nil, "Tags")
+			fs.CountP("verbose", "v", "Verbosity")
+			fs.String("level", "warn", "Log level")
+			fs.Int("port", 8080, "Port")
+			if tt.env != "" {
+				fs.BindEnv("level", "APP_LEVEL")
+			}
+
+			var got []string
+			for _, name := range []string{"tag", "verbose", "level", "port"} {
+				if err := fs.OnSet(name, func(value string) {
+					got = append(got, name+"="+value)
+				}); err != nil {
+					t.Fatalf("OnSet() error = %v", err)
+				}
+			}
+
+			if tt.config != "" {
+				if err := cmd.LoadConfig(strings.NewReader(tt.config), ConfigJSON); err != nil {
+					t.Fatalf("LoadConfig() error = %v", err)
+				}
+			}
+
+			err := cmd.ParseSilent(tt.args)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("ParseSilent() error = %v", err)
+			case tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr):
+				t.Fatalf("ParseSilent() error = %v, want %q", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("OnSet callbacks = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOverflowingFlagName(t *testing.T) {
+	tests := []struct {
+		name    string
+		padding int
+		want    string
+	}{
+		{
+			name:    "default padding",
+			padding: 4,
+			want: "General:\n" +
+				"  -v, --verbose    Verbose output\n" +
+				"      --a-very-long-flag-name    Long flag\n",
+		},
+		{
+			name:    "narrow padding",
+			padding: 2,
+			want: "General:\n" +
+				"  -v, --verbose  Verbose output\n" +
+				"      --a-very-long-flag-name  Long flag\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := New().NewFlagSet("General")
+			fs.Padding = tt.padding
+			fs.BoolP("verbose", "v", false, "Verbose output")
+			fs.Bool("a-very-long-flag-name", false, "Long flag")
+
+			// Align on the short name only, as if the long flag was added
+			// after the column was computed.
+			fs.computePadding(len("verbose"))
+
+			if got := fs.ToString(); got != tt.want {
+				t.Errorf("ToString() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlagSetAlias(t *testing.T) {
+	tests := []struct {
+		name   string
+		lookup string
+		found  bool
+	}{
+		{name: "name", lookup: "Database Options", found: true},
+		{name: "alias", lookup: "db", found: true},
+		{name: "second alias", lookup: "database", found: true},
+		{name: "unknown", lookup: "dbs"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := newTestCommand(&out)
+			db := cmd.NewFlagSet("Database Options")
+			db.String("db-host", "localhost", "Database host")
+			db.AddAlias("db")
+			db.AddAlias("database")
+
+			if got := cmd.LookupFlagSet(tt.lookup); (got == db) != tt.found {
+				t.Errorf("LookupFlagSet(%q) = %v, want found %v", tt.lookup, got, tt.found)
+			}
+
+			got, err := cmd.RenderFlagSet(tt.lookup)
+			if !tt.found {
+				if err == nil {
+					t.Errorf("RenderFlagSet(%q) error = nil, want an error", tt.lookup)
+				}
+				return
+			}
+			want := "Database Options:\n      --db-host    Database host (default: \"localhost\")\n"
+			if err != nil || got != want {
+				t.Errorf("RenderFlagSet(%q) = %q, %v, want %q", tt.lookup, got, err, want)
+			}
+
+			if strings.Contains(cmd.UsageString(), "db:") {
+				t.Error("help output shows an alias")
+			}
+		})
+	}
+}
+
+func TestShowExamples(t *testing.T) {
+	tests := []struct {
+		name string
+		show bool
+		want string
+	}{
+		{
+			name: "shown",
+			show: true,
+			want: "Database:\n" +
+				"      --db-host    Database host (e.g. db.example.com)\n" +
+				"      --db-name    Database name\n",
+		},
+		{
+			name: "hidden",
+			want: "Database:\n" +
+				"      --db-host    Database host\n" +
+				"      --db-name    Database name\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := New()
+			cmd.ShowExamples = tt.show
+			fs := cmd.NewFlagSet("Database")
+			fs.String("db-host", "", "Database host")
+			fs.String("db-name", "", "Database name")
+			if err := fs.SetExample("db-host", "db.example.com"); err != nil {
+				t.Fatalf("SetExample() error = %v", err)
+			}
+
+			got, err := cmd.RenderFlagSet("Database")
+			if err != nil {
+				t.Fatalf("RenderFlagSet() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("RenderFlagSet() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultLeader(t *testing.T) {
+	tests := []struct {
+		name   string
+		leader string
+		want   string
+	}{
+		{
+			name:   "dots",
+			leader: ".",
+			want: "Database:\n" +
+				"      --db-host    Database host ... (default: \"localhost\")\n" +
+				"      --db-port    Database port .......... (default: 5432)\n" +
+				"      --db-name    Database name\n" +
+				"      --db-opts    A very long usage text that does not\n" +
+				"                   leave any room for a\n" +
+				"                   leader (default: \"a\")\n",
+		},
+		{
+			name: "none",
+			want: "Database:\n" +
+				"      --db-host    Database host (default: \"localhost\")\n" +
+				"      --db-port    Database port (default: 5432)\n" +
+				"      --db-name    Database name\n" +
+				"      --db-opts    A very long usage text that does not\n" +
+				"                   leave any room for a\n" +
+				"                   leader (default: \"a\")\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := New()
+			cmd.DefaultLeader = tt.leader
+			cmd.Width = 60
+			fs := cmd.NewFlagSet("Database")
+			fs.String("db-host", "localhost", "Database host")
+			fs.Int("db-port", 5432, "Database port")
+			fs.String("db-name", "", "Database name")
+			fs.String("db-opts", "a", "A very long usage text that does not leave any room for a leader")
+
+			got, err := cmd.RenderFlagSet("Database")
+			if err != nil {
+				t.Fatalf("RenderFlagSet() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("RenderFlagSet() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHideFlags(t *testing.T) {
+	var out bytes.Buffer
+	cmd := newTestCommand(&out)
+	cmd.NewFlagSet("General").BoolP("verbose", "v", false, "Verbose output")
+	cloud := cmd.NewFlagSet("Cloud")
+	cloud.Description = "(available in v2)"
+	cloud.Footer = "See the roadmap."
+	cloud.HideFlags = true
+	region := cloud.String("region", "us-east-1", "Cloud region")
+	cloud.String("a-very-long-cloud-flag", "", "Not shown")
+
+	want := "app\n" +
+		"General:\n" +
+		"  -v, --verbose    Verbose output\n" +
+		"\n" +
+		"Cloud:\n" +
+		"  (available in v2)\n" +
+		"  See the roadmap.\n"
+
+	if got := cmd.UsageString(); got != want {
+		t.Errorf("UsageString() =\n%s\nwant:\n%s", got, want)
+	}
+
+	// The flags are hidden from help only
+	if err := cmd.ParseArgs([]string{"--region", "eu-west-1"}); err != nil {
+		t.Fatalf("ParseArgs() error = %v", err)
+	}
+	if *region != "eu-west-1" {
+		t.Errorf("region = %q, want eu-west-1", *region)
+	}
+}
+
+func TestDefaultFormatter(t *testing.T) {
+	brackets := func(f *pflag.Flag) string {
+		if f.Value.Type() == "bool" {
+			return ""
+		}
+		return "[default: " + f.DefValue + "]"
+	}
+	omit := func(*pflag.Flag) string { return "" }
+
+	tests := []struct {
+		name       string
+		cmdFormat  func(*pflag.Flag) string
+		flagFormat func(*pflag.Flag) string
+		want       string
+	}{
+		{
+			name: "default rendering",
+			want: "      --verbose    Verbose output\n" +
+				"      --db-host    Database host (default: \"localhost\")\n" +
+				"      --db-port    Database port (default: 5432)\n",
+		},
+		{
+			name:      "command formatter",
+			cmdFormat: brackets,
+			want: "      --verbose    Verbose output\n" +
+				"      --db-host    Database host [default: localhost]\n" +
+				"      --db-port    Database port [default: 5432]\n",
+		},
+		{
+			name:       "flag set formatter overrides the command",
+			cmdFormat:  brackets,
+			flagFormat: omit,
+			want: "      --verbose    Verbose output\n" +
+				"      --db-host    Database host\n" +
+				"      --db-port    Database port\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := New()
+			cmd.DefaultFormatter = tt.cmdFormat
+			fs := cmd.NewFlagSet("General")
+			if tt.flagFormat != nil {
+				fs.DefaultFormatter = tt.flagFormat
+			}
+			fs.Bool("verbose", false, "Verbose output")
+			fs.String("db-host", "localhost", "Database host")
+			fs.Int("db-port", 5432, "Database port")
+
+			got, err := cmd.RenderFlagSet("General")
+			if err != nil {
+				t.Fatalf("RenderFlagSet() error = %v", err)
+			}
+			if want := "General:\n" + tt.want; got != want {
+				t.Errorf("RenderFlagSet() =\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestHideDefaults(t *testing.T) {
+	tests := []struct {
+		name        string
+		cmdHide     bool
+		generalHide bool
+		want        string
+	}{
+		{
+			name: "shown",
+			want: "General:\n" +
+				"      --format    Output format (default: \"text\")\n" +
+				"\n" +
+				"Database:\n" +
+				"      --db-port    Database port (default: 5432)\n",
+		},
+		{
+			name:        "hidden in one group",
+			generalHide: true,
+			want: "General:\n" +
+				"      --format    Output format\n" +
+				"\n" +
+				"Database:\n" +
+				"      --db-port    Database port (default: 5432)\n",
+		},
+		{
+			name:    "hidden in every group",
+			cmdHide: true,
+			want: "General:\n" +
+				"      --format    Output format\n" +
+				"\n" +
+				"Database:\n" +
+				"      --db-port    Database port\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := newTestCommand(&out)
+			cmd.AlignUsagePerFlagSet = true
+			cmd.HideDefaults = tt.cmdHide
+			general := cmd.NewFlagSet("General")
+			if tt.generalHide {
+				general.HideDefaults = true
+			}
+			general.String("format", "text", "Output format")
+			general.String("secret", "hunter2", "Hidden secret")
+			general.MarkHidden("secret")
+			cmd.NewFlagSet("Database").Int("db-port", 5432, "Database port")
+
+			if got := cmd.UsageString(); got != "app\n"+tt.want {
+				t.Errorf("UsageString() =\n%s\nwant:\n%s", got, "app\n"+tt.want)
+			}
+		})
+	}
+}
+
+func TestCountFlag(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{name: "unset", want: 0},
+		{name: "combined", args: []string{"-vvv"}, want: 3},
+		{name: "repeated", args: []string{"-v", "-v"}, want: 2},
+		{name: "long and short", args: []string{"--verbose", "-vv"}, want: 3},
+		{name: "explicit value", args: []string{"--verbose=5"}, want: 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := New()
+			verbose := cmd.NewFlagSet("General").CountP("verbose", "v", "Increase verbosity")
+
+			if err := cmd.ParseArgs(tt.args); err != nil {
+				t.Fatalf("ParseArgs() error = %v", err)
+			}
+			if *verbose != tt.want {
+				t.Errorf("verbose = %d, want %d", *verbose, tt.want)
+			}
+		})
+	}
+}
+
+func TestCountFlagHelp(t *testing.T) {
+	tests := []struct {
+		name      string
+		showTypes bool
+		want      string
+	}{
+		{
+			name: "no default",
+			want: "  -v, --verbose    Increase verbosity\n",
+		},
+		{
+			name:      "count type",
+			showTypes: true,
+			want:      "  -v, --verbose count    Increase verbosity\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := New()
+			cmd.ShowTypes = tt.showTypes
+			cmd.NewFlagSet("General").CountP("verbose", "v", "Increase verbosity")
+
+			got, err := cmd.RenderFlagSet("General")
+			if err != nil {
+				t.Fatalf("RenderFlagSet() error = %v", err)
+			}
+			if want := "General:\n" + tt.want; got != want {
+				t.Errorf("RenderFlagSet() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestSetValidator(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		wantErr   string
+		wantCalls []string
+		wantPort  int
+	}{
+		{name: "default not validated", wantPort: 80},
+		{name: "valid", args: []string{"--port", "8080"}, wantCalls: []string{"first", "second"}, wantPort: 8080},
+		{
+			name:      "first fails",
+			args:      []string{"--port", "443"},
+			wantErr:   `invalid argument "443" for "--port" flag: port is privileged`,
+			wantCalls: []string{"first"},
+		},
+		{
+			name:      "second fails",
+			args:      []string{"--port", "8081"},
+			wantErr:   `invalid argument "8081" for "--port" flag: port is odd`,
+			wantCalls: []string{"first", "second"},
+		},
+		{
+			name:    "parse error",
+			args:    []string{"--port", "http"},
+			wantErr: `invalid argument "http" for "--port" flag: strconv.ParseInt: parsing "http": invalid syntax`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := New()
+			fs := cmd.NewFlagSet("Server")
+			port := fs.Int("port", 80, "Port to listen on")
+
+			var calls []string
+			fs.SetValidator("port", func(string) error {
+				calls = append(calls, "first")
+				if *port < 1024 {
+					return errors.New("port is privileged")
+				}
+				return nil
+			})
+			fs.SetValidator("port", func(string) error {
+				calls = append(calls, "second")
+				if *port%2 != 0 {
+					return errors.New("port is odd")
+				}
+				return nil
+			})
+
+			err := cmd.ParseSilent(tt.args)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("ParseSilent() error = %v", err)
+			case tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr):
+				t.Fatalf("ParseSilent() error = %v, want %q", err, tt.wantErr)
+			}
+			if !slices.Equal(calls, tt.wantCalls) {
+				t.Errorf("validators called %q, want %q", calls, tt.wantCalls)
+			}
+			if tt.wantErr == "" && *port != tt.wantPort {
+				t.Errorf("port = %d, want %d", *port, tt.wantPort)
+			}
+		})
+	}
+
+	if err := New().NewFlagSet("Server").SetValidator("port", func(string) error { return nil }); err == nil {
+		t.Error("SetValidator() error = nil, want an error for an unknown flag")
+	}
+}
+
+func TestMarkDeprecated(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "not used"},
+		{name: "replacement used", args: []string{"--db-password", "secret"}},
+		{
+			name: "deprecated flag used",
+			args: []string{"--db-pass", "secret"},
+			want: "Flag --db-pass has been deprecated, use --db-password\n",
+		},
+		{name: "long form of a deprecated shorthand", args: []string{"--db-port", "6543"}},
+		{
+			name: "deprecated shorthand used",
+			args: []string{"-p", "6543"},
+			want: "Flag shorthand -p has been deprecated, use --db-port\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := newTestCommand(&out)
+			fs := cmd.NewFlagSet("Database")
+			fs.String("db-password", "", "Database password")
+			fs.String("db-pass", "", "Database password")
+			fs.IntP("db-port", "p", 5432, "Database port")
+			fs.MarkDeprecated("db-pass", "use --db-password")
+			fs.MarkShorthandDeprecated("db-port", "use --db-port")
+
+			if err := cmd.ParseArgs(tt.args); err != nil {
+				t.Fatalf("ParseArgs() error = %v", err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("ParseArgs() wrote %q, want %q", out.String(), tt.want)
+			}
+
+			want := "app\n" +
+				"Database:\n" +
+				"      --db-password    Database password\n" +
+				"      --db-port        Database port (default: 5432)\n"
+			if got := cmd.UsageString(); got != want {
+				t.Errorf("UsageString() =\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestAlias(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "unset", want: "-"},
+		{name: "canonical", args: []string{"--output", "a.txt"}, want: "a.txt"},
+		{name: "alias", args: []string{"--out", "b.txt"}, want: "b.txt"},
+		{name: "both", args: []string{"--out", "b.txt", "--output", "a.txt"}, want: "a.txt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := newTestCommand(&out)
+			fs := cmd.NewFlagSet("General")
+			output := fs.StringP("output", "o", "-", "Output file")
+			if err := fs.Alias("output", "out"); err != nil {
+				t.Fatalf("Alias() error = %v", err)
+			}
+
+			if err := cmd.ParseArgs(tt.args); err != nil {
+				t.Fatalf("ParseArgs() error = %v", err)
+			}
+			if *output != tt.want {
+				t.Errorf("output = %q, want %q", *output, tt.want)
+			}
+
+			changed := len(tt.args) > 0
+			for _, name := range []string{"output", "out"} {
+				if got := fs.Lookup(name).Changed; got != changed {
+					t.Errorf("--%s changed = %v, want %v", name, got, changed)
+				}
+			}
+
+			want := "app\n" +
+				"General:\n" +
+				"  -o, --output    Output file (default: \"-\")\n"
+			if got := cmd.UsageString(); got != want {
+				t.Errorf("UsageString() =\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestAliasErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		canonical string
+		alias     string
+	}{
+		{name: "unknown canonical flag", canonical: "missing", alias: "out"},
+		{name: "alias of the same group", canonical: "output", alias: "verbose"},
+		{name: "alias of another group", canonical: "output", alias: "db-host"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := New()
+			general := cmd.NewFlagSet("General")
+			general.String("output", "", "Output file")
+			general.Bool("verbose", false, "Verbose output")
+			cmd.NewFlagSet("Database").String("db-host", "", "Database host")
+
+			if err := general.Alias(tt.canonical, tt.alias); err == nil {
+				t.Errorf("Alias(%q, %q) error = nil, want an error", tt.canonical, tt.alias)
+			}
+		})
+	}
+}
+
+func TestShowTypes(t *testing.T) {
+	tests := []struct {
+		name      string
+		perGroup  bool
+		boolTypes bool
+		want      string
+	}{
+		{
+			name: "global alignment",
+			want: "General:\n" +
+				"  -c, --config  string         Config file\n" +
+				"  -v, --verbose                Verbose output\n" +
+				"\n" +
+				"Database:\n" +
+				"      --db-port    int         Database port (default: 5432)\n" +
+				"      --db-timeout duration    Query timeout (default: 5s)\n",
+		},
+		{
+			name:     "per group alignment",
+			perGroup: true,
+			want: "General:\n" +
+				"  -c, --config  string    Config file\n" +
+				"  -v, --verbose           Verbose output\n" +
+				"\n" +
+				"Database:\n" +
+				"      --db-port    int         Database port (default: 5432)\n" +
+				"      --db-timeout duration    Query timeout (default: 5s)\n",
+		},
+		{
+			name:      "bool types",
+			perGroup:  true,
+			boolTypes: true,
+			want: "General:\n" +
+				"  -c, --config  string    Config file\n" +
+				"  -v, --verbose bool      Verbose output\n" +
+				"\n" +
+				"Database:\n" +
+				"      --db-port    int         Database port (default: 5432)\n" +
+				"      --db-timeout duration    Query timeout (default: 5s)\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := newTestCommand(&out)
+			cmd.ShowTypes = true
+			cmd.ShowBoolTypes = tt.boolTypes
+			cmd.AlignUsagePerFlagSet = tt.perGroup
+			general := cmd.NewFlagSet("General")
+			general.StringP("config", "c", "", "Config file")
+			general.BoolP("verbose", "v", false, "Verbose output")
+			db := cmd.NewFlagSet("Database")
+			db.Int("db-port", 5432, "Database port")
+			db.Duration("db-timeout", 5*time.Second, "Query timeout")
+
+			if got := cmd.UsageString(); got != "app\n"+tt.want {
+				t.Errorf("UsageString() =\n%s\nwant:\n%s", got, "app\n"+tt.want)
+			}
+		})
+	}
+}
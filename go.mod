@@ -2,4 +2,10 @@ module github.com/d3mondev/pflagx
 
 go 1.24.0
 
-require github.com/spf13/pflag v1.0.6
+require (
+	github.com/BurntSushi/toml v1.5.0
+	github.com/spf13/pflag v1.0.6
+	golang.org/x/sys v0.37.0
+	golang.org/x/term v0.36.0
+	gopkg.in/yaml.v3 v3.0.1
+)
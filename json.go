@@ -0,0 +1,102 @@
+package pflagx
+
+import (
+	"encoding/json"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// flagJSON is the JSON representation of a flag used by FlagsJSON.
+type flagJSON struct {
+	Name      string `json:"name"`
+	Shorthand string `json:"shorthand,omitempty"`
+	Type      string `json:"type"`
+	Value     any    `json:"value"`
+	Default   any    `json:"default"`
+	Changed   bool   `json:"changed"`
+	Group     string `json:"group"`
+}
+
+// FlagsJSON returns a JSON array describing every flag of the Command, with
+// its name, shorthand, type, current value, default value, whether it was
+// changed, and the Name of its FlagSet. Values are typed where possible, so
+// booleans and numbers are not quoted and slices are arrays. It is meant to
+// be called after parsing. Hidden flags are included only if includeHidden
+// is true.
+func (cmd *Command) FlagsJSON(includeHidden bool) ([]byte, error) {
+	flags := make([]flagJSON, 0)
+
+	flagSets := cmd.flagSets
+	if versionGroup := cmd.placeVersionFlag(); versionGroup != nil {
+		flagSets = append(slices.Clone(flagSets), versionGroup)
+	}
+
+	for _, fs := range flagSets {
+		fs.visitAllFlags(func(f *pflag.Flag) {
+			if f.Hidden && !includeHidden {
+				return
+			}
+
+			flags = append(flags, flagJSON{
+				Name:      f.Name,
+				Shorthand: f.Shorthand,
+				Type:      f.Value.Type(),
+				Value:     jsonFlagValue(f.Value),
+				Default:   jsonFlagDefault(f),
+				Changed:   f.Changed,
+				Group:     fs.Name,
+			})
+		})
+	}
+
+	return json.Marshal(flags)
+}
+
+// jsonFlagValue returns the current value of a flag as a typed JSON value.
+func jsonFlagValue(v pflag.Value) any {
+	if slice, ok := baseValue(v).(pflag.SliceValue); ok {
+		return jsonSlice(v.Type(), slice.GetSlice())
+	}
+	return jsonScalar(v.Type(), v.String())
+}
+
+// jsonFlagDefault returns the default value of a flag as a typed JSON value,
+// typed the same way as the current value.
+func jsonFlagDefault(f *pflag.Flag) any {
+	if _, ok := baseValue(f.Value).(pflag.SliceValue); ok {
+		return jsonSlice(f.Value.Type(), sliceElems(f.DefValue))
+	}
+	return jsonScalar(f.Value.Type(), f.DefValue)
+}
+
+// jsonSlice returns the elements of a slice flag as a JSON array, each typed
+// according to the type of the flag without its "Slice" suffix.
+func jsonSlice(typ string, elems []string) []any {
+	values := make([]any, len(elems))
+	for i, elem := range elems {
+		values[i] = jsonScalar(strings.TrimSuffix(typ, "Slice"), elem)
+	}
+	return values
+}
+
+// jsonScalar returns s as a JSON boolean or number according to the type of
+// the flag, or as a string if the type is not numeric or s does not parse.
+func jsonScalar(typ, s string) any {
+	switch typ {
+	case "bool":
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b
+		}
+	case "int", "int8", "int16", "int32", "int64",
+		"uint", "uint8", "uint16", "uint32", "uint64",
+		"float32", "float64", "count":
+		// Go syntax such as "+Inf" is not valid JSON
+		if _, err := strconv.ParseFloat(s, 64); err == nil && json.Valid([]byte(s)) {
+			return json.Number(s)
+		}
+	}
+	return s
+}
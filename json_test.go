@@ -0,0 +1,58 @@
+package pflagx
+
+import "testing"
+
+func TestFlagsJSON(t *testing.T) {
+	tests := []struct {
+		name          string
+		includeHidden bool
+		want          string
+	}{
+		{
+			name: "visible flags",
+			want: `[` +
+				`{"name":"verbose","shorthand":"v","type":"bool","value":true,"default":false,"changed":true,"group":"General"},` +
+				`{"name":"tags","type":"stringSlice","value":["a","b"],"default":[],"changed":true,"group":"General"},` +
+				`{"name":"db-port","type":"int","value":5432,"default":5432,"changed":false,"group":"Database"},` +
+				`{"name":"ratio","type":"float64","value":0.25,"default":0.5,"changed":true,"group":"Database"}` +
+				`]`,
+		},
+		{
+			name:          "hidden flags",
+			includeHidden: true,
+			want: `[` +
+				`{"name":"verbose","shorthand":"v","type":"bool","value":true,"default":false,"changed":true,"group":"General"},` +
+				`{"name":"tags","type":"stringSlice","value":["a","b"],"default":[],"changed":true,"group":"General"},` +
+				`{"name":"secret","type":"string","value":"","default":"","changed":false,"group":"General"},` +
+				`{"name":"db-port","type":"int","value":5432,"default":5432,"changed":false,"group":"Database"},` +
+				`{"name":"ratio","type":"float64","value":0.25,"default":0.5,"changed":true,"group":"Database"}` +
+				`]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := New()
+			general := cmd.NewFlagSet("General")
+			general.BoolP("verbose", "v", false, "Verbose output")
+			general.StringSlice("tags", nil, "Tags")
+			general.String("secret", "", "Hidden secret")
+			general.MarkHidden("secret")
+			db := cmd.NewFlagSet("Database")
+			db.Int("db-port", 5432, "Database port")
+			db.Float64("ratio", 0.5, "Sample ratio")
+
+			if err := cmd.ParseSilent([]string{"-v", "--tags", "a,b", "--ratio", "0.25"}); err != nil {
+				t.Fatalf("ParseSilent() error = %v", err)
+			}
+
+			got, err := cmd.FlagsJSON(tt.includeHidden)
+			if err != nil {
+				t.Fatalf("FlagsJSON() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("FlagsJSON() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package pflagx
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// troffEscaper escapes the characters that have a meaning in troff.
+var troffEscaper = strings.NewReplacer(
+	`\`, `\e`,
+	"-", `\-`,
+)
+
+// ManPage returns the Command as a troff man page in section 1, with one
+// section per FlagSet named after the FlagSet Name in uppercase. Hidden
+// flags are skipped, as in help output. It returns an error if the Command
+// has no Name.
+func (cmd *Command) ManPage() ([]byte, error) {
+	if cmd.Name == "" {
+		return nil, errors.New("man page requires a command name")
+	}
+
+	var buf bytes.Buffer
+
+	// Title
+	buf.WriteString(`.TH "`)
+	buf.WriteString(strings.ToUpper(troffEscape(cmd.Name)))
+	buf.WriteString(`" 1 "`)
+	buf.WriteString(time.Now().Format("2006-01-02"))
+	buf.WriteString(`" "`)
+	buf.WriteString(troffEscape(strings.TrimSpace(cmd.Name + " " + cmd.Version)))
+	buf.WriteString("\"\n")
+
+	// Name and summary
+	summary, _, _ := strings.Cut(cmd.Description, "\n")
+	buf.WriteString(".SH NAME\n")
+	buf.WriteString(troffEscape(cmd.Name))
+	if summary != "" {
+		buf.WriteString(` \- `)
+		buf.WriteString(troffEscape(summary))
+	}
+	buf.WriteByte('\n')
+
+	// Synopsis
+	buf.WriteString(".SH SYNOPSIS\n")
+	parts := cmd.synopsisParts()
+	if cmd.Name != "" {
+		buf.WriteString(".B ")
+		buf.WriteString(troffEscape(parts[0]))
+		buf.WriteByte('\n')
+		parts = parts[1:]
+	}
+	if len(parts) > 0 {
+		buf.WriteString(troffEscape(strings.Join(parts, " ")))
+		buf.WriteByte('\n')
+	}
+
+	// Description
+	if cmd.Description != "" {
+		buf.WriteString(".SH DESCRIPTION\n")
+		writeTroffText(&buf, cmd.Description)
+	}
+
+	for _, fs := range cmd.shownFlagSets() {
+		// Skip the FlagSet if there is nothing to output.
+		if fs.maxNameLength(nil) == 0 && fs.Description == "" && fs.Footer == "" {
+			continue
+		}
+
+		writeTroffFlagSet(&buf, fs, cmd.DocAssignmentStyle)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// writeTroffFlagSet writes the FlagSet as a man page section, with a tagged
+// paragraph for each flag. Value flags are shown in the given style.
+func writeTroffFlagSet(buf *bytes.Buffer, fs *FlagSet, style DocAssignmentStyle) {
+	buf.WriteString(".SH ")
+	if fs.Name != "" {
+		buf.WriteString(`"`)
+		buf.WriteString(troffEscape(strings.ToUpper(fs.Name)))
+		buf.WriteString(`"`)
+	} else {
+		buf.WriteString("OPTIONS")
+	}
+	buf.WriteByte('\n')
+
+	// Description of the FlagSet
+	if fs.Description != "" {
+		writeTroffText(buf, fs.Description)
+	}
+
+	// Flags
+	fs.visitFlags(nil, func(f *pflag.Flag) {
+		buf.WriteString(".TP\n")
+		if f.Shorthand != "" {
+			buf.WriteString(`\fB\-`)
+			buf.WriteString(troffEscape(f.Shorthand))
+			buf.WriteString(`\fR, `)
+		}
+		buf.WriteString(`\fB\-\-`)
+		buf.WriteString(troffEscape(f.Name))
+		buf.WriteString(`\fR`)
+		if f.Value.Type() != "bool" {
+			buf.WriteString(style.separator())
+			buf.WriteString(`\fI`)
+			buf.WriteString(troffEscape(f.Value.Type()))
+			buf.WriteString(`\fR`)
+		}
+		buf.WriteByte('\n')
+
+		usage := f.Usage
+		if def := fs.defaultString(f); def != "" {
+			usage += " " + def
+		}
+		writeTroffLines(buf, usage)
+	})
+
+	// Footer
+	if fs.Footer != "" {
+		buf.WriteString(".PP\n.nf\n")
+		writeTroffLines(buf, strings.TrimSuffix(fs.Footer, "\n"))
+		buf.WriteString(".fi\n")
+	}
+}
+
+// writeTroffText writes s as man page paragraphs, keeping its line breaks.
+func writeTroffText(buf *bytes.Buffer, s string) {
+	buf.WriteString(".PP\n.nf\n")
+	writeTroffLines(buf, strings.TrimSuffix(s, "\n"))
+	buf.WriteString(".fi\n")
+}
+
+// writeTroffLines writes each line of s escaped for troff.
+func writeTroffLines(buf *bytes.Buffer, s string) {
+	for line := range strings.SplitSeq(s, "\n") {
+		buf.WriteString(troffEscape(line))
+		buf.WriteByte('\n')
+	}
+}
+
+// troffEscape escapes s so that it is written literally by troff, including
+// lines starting with a control character.
+func troffEscape(s string) string {
+	s = troffEscaper.Replace(s)
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		s = `\&` + s
+	}
+	return s
+}
@@ -0,0 +1,126 @@
+package pflagx
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// manBody returns the man page of cmd without its title line, which holds
+// the current date.
+func manBody(t *testing.T, cmd *Command) string {
+	t.Helper()
+
+	b, err := cmd.ManPage()
+	if err != nil {
+		t.Fatalf("ManPage() error = %v", err)
+	}
+
+	title, body, _ := strings.Cut(string(b), "\n")
+	if !strings.HasPrefix(title, ".TH ") {
+		t.Fatalf("ManPage() starts with %q, want a .TH line", title)
+	}
+	return body
+}
+
+func TestManDocAssignmentStyle(t *testing.T) {
+	tests := []struct {
+		name  string
+		style DocAssignmentStyle
+		want  string
+	}{
+		{
+			name:  "space",
+			style: DocAssignmentSpace,
+			want:  `\fB\-c\fR, \fB\-\-config\fR \fIstring\fR`,
+		},
+		{
+			name:  "equals",
+			style: DocAssignmentEquals,
+			want:  `\fB\-c\fR, \fB\-\-config\fR=\fIstring\fR`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := New()
+			cmd.Name = "app"
+			cmd.DocAssignmentStyle = tt.style
+			fs := cmd.NewFlagSet("General")
+			fs.StringP("config", "c", "", "Config file")
+			fs.Bool("verbose", false, "Verbose output")
+
+			want := ".SH NAME\n" +
+				"app\n" +
+				".SH SYNOPSIS\n" +
+				".B app\n" +
+				"[flags]\n" +
+				".SH \"GENERAL\"\n" +
+				".TP\n" +
+				tt.want + "\n" +
+				"Config file\n" +
+				".TP\n" +
+				`\fB\-\-verbose\fR` + "\n" +
+				"Verbose output\n"
+			if got := manBody(t, cmd); got != want {
+				t.Errorf("ManPage() =\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestManPage(t *testing.T) {
+	cmd := New()
+	cmd.Name = "app"
+	cmd.Version = "v1.0.0"
+	cmd.Description = "Process files.\nReads each file and prints a report."
+
+	general := cmd.NewFlagSet("General Options")
+	general.BoolP("verbose", "v", false, "Verbose output")
+	general.String("secret", "", "Hidden secret")
+	general.MarkHidden("secret")
+	db := cmd.NewFlagSet("Database")
+	db.IntP("db-port", "p", 5432, "Database port")
+
+	b, err := cmd.ManPage()
+	if err != nil {
+		t.Fatalf("ManPage() error = %v", err)
+	}
+
+	title, _, _ := strings.Cut(string(b), "\n")
+	wantTitle := `.TH "APP" 1 "` + time.Now().Format("2006-01-02") + `" "app v1.0.0"`
+	if title != wantTitle {
+		t.Errorf("ManPage() title = %q, want %q", title, wantTitle)
+	}
+
+	want := ".SH NAME\n" +
+		`app \- Process files.` + "\n" +
+		".SH SYNOPSIS\n" +
+		".B app\n" +
+		"[flags]\n" +
+		".SH DESCRIPTION\n" +
+		".PP\n" +
+		".nf\n" +
+		"Process files.\n" +
+		"Reads each file and prints a report.\n" +
+		".fi\n" +
+		".SH \"GENERAL OPTIONS\"\n" +
+		".TP\n" +
+		`\fB\-v\fR, \fB\-\-verbose\fR` + "\n" +
+		"Verbose output\n" +
+		".TP\n" +
+		`\fB\-\-version\fR` + "\n" +
+		"Print the version and exit\n" +
+		".SH \"DATABASE\"\n" +
+		".TP\n" +
+		`\fB\-p\fR, \fB\-\-db\-port\fR \fIint\fR` + "\n" +
+		"Database port (default: 5432)\n"
+	if got := manBody(t, cmd); got != want {
+		t.Errorf("ManPage() =\n%s\nwant:\n%s", got, want)
+	}
+
+	cmd.Name = ""
+	if _, err := cmd.ManPage(); err == nil {
+		t.Error("ManPage() without a name error = nil, want an error")
+	}
+}
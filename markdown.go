@@ -0,0 +1,195 @@
+package pflagx
+
+import (
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// markdownEscaper escapes the characters that have a meaning in Markdown.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	"`", "\\`",
+	"*", `\*`,
+	"_", `\_`,
+	"[", `\[`,
+	"]", `\]`,
+	"<", `\<`,
+	">", `\>`,
+	"|", `\|`,
+	"#", `\#`,
+)
+
+// DocAssignmentStyle determines how the value of a value flag is shown in
+// generated documentation, such as Markdown and man pages.
+type DocAssignmentStyle int
+
+const (
+	// DocAssignmentSpace separates the flag from its value with a space,
+	// e.g. "--config string".
+	DocAssignmentSpace DocAssignmentStyle = iota
+
+	// DocAssignmentEquals separates the flag from its value with an equals
+	// sign, e.g. "--config=string".
+	DocAssignmentEquals
+)
+
+// separator returns the text written between a flag and its value.
+func (style DocAssignmentStyle) separator() string {
+	if style == DocAssignmentEquals {
+		return "="
+	}
+	return " "
+}
+
+// Markdown returns a Markdown reference of the Command. Each FlagSet is
+// rendered as a section with its Name as heading, its Description as a
+// paragraph, a table of its flags, and its Footer as a fenced code block.
+// Hidden flags are skipped, as in help output.
+func (cmd *Command) Markdown() string {
+	sb := strings.Builder{}
+
+	// Program name and version
+	if cmd.Name != "" {
+		sb.WriteString("# ")
+		sb.WriteString(markdownEscaper.Replace(cmd.Name))
+		sb.WriteString("\n\n")
+	}
+	if cmd.Version != "" {
+		sb.WriteString("Version: ")
+		sb.WriteString(markdownEscaper.Replace(cmd.Version))
+		sb.WriteString("\n\n")
+	}
+
+	// Description
+	if cmd.Description != "" {
+		writeMarkdownParagraph(&sb, cmd.Description)
+	}
+
+	for _, fs := range cmd.shownFlagSets() {
+		// Skip the FlagSet if there is nothing to output.
+		if fs.maxNameLength(nil) == 0 && fs.Description == "" && fs.Footer == "" {
+			continue
+		}
+
+		sb.WriteString(fs.markdown(cmd.DocAssignmentStyle))
+	}
+
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// Markdown returns the Markdown section of the FlagSet, made of its Name as
+// heading, its Description as a paragraph, a table of its flags, and its
+// Footer as a fenced code block, marked as shell code if FooterIsCode is set.
+// Value flags are shown with the DocAssignmentStyle of their Command.
+func (s *FlagSet) Markdown() string {
+	var style DocAssignmentStyle
+	if s.cmd != nil {
+		style = s.cmd.DocAssignmentStyle
+	}
+	return s.markdown(style)
+}
+
+// markdown returns the Markdown section of the FlagSet, with value flags
+// shown in the given style.
+func (s *FlagSet) markdown(style DocAssignmentStyle) string {
+	sb := strings.Builder{}
+
+	// Name of the FlagSet
+	if s.Name != "" && !s.HideName {
+		sb.WriteString("## ")
+		sb.WriteString(markdownEscaper.Replace(s.Name))
+		sb.WriteString("\n\n")
+	}
+
+	// Description of the FlagSet
+	if s.Description != "" {
+		writeMarkdownParagraph(&sb, s.Description)
+	}
+
+	// Table of flags
+	header := false
+	s.visitFlags(nil, func(f *pflag.Flag) {
+		if !header {
+			sb.WriteString("| Shorthand | Flag | Type | Default | Usage |\n")
+			sb.WriteString("| --- | --- | --- | --- | --- |\n")
+			header = true
+		}
+
+		shorthand := ""
+		if f.Shorthand != "" {
+			shorthand = "`-" + f.Shorthand + "`"
+		}
+
+		def := s.defaultValue(f)
+		switch {
+		case def == "":
+		case s.DefaultFormatter != nil:
+			def = markdownEscaper.Replace(def)
+		default:
+			def = markdownCode(def)
+		}
+
+		usage := strings.ReplaceAll(markdownEscaper.Replace(f.Usage), "\n", "<br>")
+
+		sb.WriteString("| ")
+		sb.WriteString(shorthand)
+		sb.WriteString(" | `--")
+		sb.WriteString(f.Name)
+		if f.Value.Type() != "bool" {
+			sb.WriteString(style.separator())
+			sb.WriteString(f.Value.Type())
+		}
+		sb.WriteString("` | ")
+		sb.WriteString(f.Value.Type())
+		sb.WriteString(" | ")
+		sb.WriteString(def)
+		sb.WriteString(" | ")
+		sb.WriteString(usage)
+		sb.WriteString(" |\n")
+	})
+	if header {
+		sb.WriteByte('\n')
+	}
+
+	// Footer
+	if s.Footer != "" {
+		sb.WriteString("```")
+		if s.FooterIsCode {
+			sb.WriteString("sh")
+		}
+		sb.WriteByte('\n')
+		sb.WriteString(strings.TrimSuffix(s.Footer, "\n"))
+		sb.WriteString("\n```\n\n")
+	}
+
+	return sb.String()
+}
+
+// writeMarkdownParagraph writes s as escaped Markdown paragraphs, keeping
+// its line breaks with hard breaks.
+func writeMarkdownParagraph(sb *strings.Builder, s string) {
+	lines := strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+	for i, line := range lines {
+		sb.WriteString(markdownEscaper.Replace(line))
+		if line != "" && i+1 < len(lines) && lines[i+1] != "" {
+			sb.WriteString("  ")
+		}
+		sb.WriteByte('\n')
+	}
+	sb.WriteByte('\n')
+}
+
+// markdownCode returns s as Markdown inline code, escaping the pipes so
+// that it can be used in a table cell.
+func markdownCode(s string) string {
+	if s == "" {
+		return ""
+	}
+
+	fence := "`"
+	if strings.Contains(s, "`") {
+		fence = "``"
+	}
+	return fence + strings.ReplaceAll(s, "|", `\|`) + fence
+}
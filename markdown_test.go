@@ -0,0 +1,119 @@
+package pflagx
+
+import "testing"
+
+func TestMarkdownFooterIsCode(t *testing.T) {
+	tests := []struct {
+		name   string
+		isCode bool
+		want   string
+	}{
+		{
+			name:   "code",
+			isCode: true,
+			want: "## Examples\n\n" +
+				"```sh\n" +
+				"app --verbose\n" +
+				"app --format json\n" +
+				"```\n\n",
+		},
+		{
+			name: "plain",
+			want: "## Examples\n\n" +
+				"```\n" +
+				"app --verbose\n" +
+				"app --format json\n" +
+				"```\n\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := New()
+			fs := cmd.NewFlagSet("Examples")
+			fs.Footer = "app --verbose\napp --format json"
+			fs.FooterIsCode = tt.isCode
+
+			if got := fs.Markdown(); got != tt.want {
+				t.Errorf("Markdown() =\n%s\nwant:\n%s", got, tt.want)
+			}
+
+			want := "Examples:\n  app --verbose\n  app --format json\n"
+			if got, _ := cmd.RenderFlagSet("Examples"); got != want {
+				t.Errorf("RenderFlagSet() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestMarkdownDocAssignmentStyle(t *testing.T) {
+	tests := []struct {
+		name  string
+		style DocAssignmentStyle
+		want  string
+	}{
+		{
+			name:  "space",
+			style: DocAssignmentSpace,
+			want: "| `-c` | `--config string` | string |  | Config file |\n" +
+				"|  | `--verbose` | bool |  | Verbose output |\n",
+		},
+		{
+			name:  "equals",
+			style: DocAssignmentEquals,
+			want: "| `-c` | `--config=string` | string |  | Config file |\n" +
+				"|  | `--verbose` | bool |  | Verbose output |\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := New()
+			cmd.DocAssignmentStyle = tt.style
+			fs := cmd.NewFlagSet("General")
+			fs.StringP("config", "c", "", "Config file")
+			fs.Bool("verbose", false, "Verbose output")
+
+			want := "## General\n\n" +
+				"| Shorthand | Flag | Type | Default | Usage |\n" +
+				"| --- | --- | --- | --- | --- |\n" +
+				tt.want + "\n"
+			if got := fs.Markdown(); got != want {
+				t.Errorf("Markdown() =\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestCommandMarkdown(t *testing.T) {
+	cmd := New()
+	cmd.Name = "app"
+	cmd.Version = "v1.0.0"
+	cmd.Description = "A test application."
+
+	general := cmd.NewFlagSet("General")
+	general.BoolP("verbose", "v", false, "Verbose *debug* output")
+	general.String("format", "text", "Output format: text|json")
+	general.String("secret", "", "Hidden secret")
+	general.MarkHidden("secret")
+
+	args := cmd.NewFlagSet("Arguments")
+	args.Description = "FILE...  Files to process"
+
+	want := "# app\n\n" +
+		"Version: v1.0.0\n\n" +
+		"A test application.\n\n" +
+		"## General\n\n" +
+		"| Shorthand | Flag | Type | Default | Usage |\n" +
+		"| --- | --- | --- | --- | --- |\n" +
+		"| `-v` | `--verbose` | bool |  | Verbose \\*debug\\* output |\n" +
+		"|  | `--format string` | string | `text` | Output format: text\\|json |\n" +
+		"|  | `--version` | bool |  | Print the version and exit |\n" +
+		"\n" +
+		"## Arguments\n\n" +
+		"FILE...  Files to process\n"
+
+	if got := cmd.Markdown(); got != want {
+		t.Errorf("Markdown() =\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,153 @@
+package pflagx
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// positional describes a positional argument of a Command.
+type positional struct {
+	name     string
+	required bool
+	variadic bool
+}
+
+// AddPositional adds a positional argument named name, expected after the
+// ones added before. Required arguments must precede optional ones.
+func (cmd *Command) AddPositional(name string, required bool) {
+	cmd.positionals = append(cmd.positionals, &positional{
+		name:     name,
+		required: required,
+	})
+}
+
+// AddPositionalVariadic adds a trailing positional argument named name that
+// takes every remaining argument, possibly none. It must be added last.
+func (cmd *Command) AddPositionalVariadic(name string) {
+	cmd.positionals = append(cmd.positionals, &positional{
+		name:     name,
+		variadic: true,
+	})
+}
+
+// ValidatePositionals returns an error if the number of positional arguments
+// of the last parse does not match the positional arguments added with
+// AddPositional and AddPositionalVariadic.
+func (cmd *Command) ValidatePositionals() error {
+	var required int
+	for _, p := range cmd.positionals {
+		if p.required {
+			required++
+		}
+	}
+
+	if cmd.NArg() < required {
+		return fmt.Errorf("missing required argument <%s>", cmd.positionals[cmd.NArg()].name)
+	}
+
+	if !cmd.hasVariadic() && cmd.NArg() > len(cmd.positionals) {
+		return fmt.Errorf("too many arguments: expected at most %d, got %d", len(cmd.positionals), cmd.NArg())
+	}
+
+	return nil
+}
+
+// Positional returns the value of the named positional argument, or an empty
+// string if it was not given or does not exist. For a variadic argument, it
+// returns the first value; use Args for the others.
+func (cmd *Command) Positional(name string) string {
+	for i, p := range cmd.positionals {
+		if p.name == name {
+			return cmd.Arg(i)
+		}
+	}
+	return ""
+}
+
+// Synopsis returns the usage line of the Command built from its Name, whether
+// it has visible flags, its mutually exclusive flags, and its positional
+// arguments, e.g. "myapp [flags] [--json | --yaml] <source> [filter]".
+// Required arguments are enclosed in angle brackets, optional ones in square
+// brackets, and variadic ones are followed by an ellipsis.
+func (cmd *Command) Synopsis() string {
+	return strings.Join(cmd.synopsisParts(), " ")
+}
+
+// synopsisParts returns the tokens of the Synopsis, which are never broken
+// when the usage line is wrapped.
+func (cmd *Command) synopsisParts() []string {
+	var parts []string
+	if cmd.Name != "" {
+		parts = append(parts, cmd.Name)
+	}
+
+	var hasFlags bool
+	for _, fs := range cmd.enabledFlagSets() {
+		fs.VisitAll(func(f *pflag.Flag) {
+			hasFlags = hasFlags || !f.Hidden
+		})
+	}
+	if hasFlags {
+		parts = append(parts, "[flags]")
+	}
+
+	for _, group := range cmd.exclusiveGroupsShown() {
+		parts = append(parts, "[--"+strings.Join(group, " | --")+"]")
+	}
+
+	for _, p := range cmd.positionals {
+		switch {
+		case p.variadic:
+			parts = append(parts, "["+p.name+"...]")
+		case p.required:
+			parts = append(parts, "<"+p.name+">")
+		default:
+			parts = append(parts, "["+p.name+"]")
+		}
+	}
+
+	return parts
+}
+
+// wrapSynopsis returns the usage line made of prefix followed by the tokens
+// of the Synopsis, wrapped to width between tokens. Continuation lines are
+// aligned under the first token following the program name.
+func (cmd *Command) wrapSynopsis(prefix string, width int) string {
+	parts := cmd.synopsisParts()
+	if len(parts) == 0 {
+		return ""
+	}
+
+	indent := len(prefix)
+	if cmd.Name != "" && len(parts) > 1 {
+		indent += len(parts[0]) + 1
+	}
+
+	// Fall back to aligning under the program name if it is too long.
+	if indent > width/2 {
+		indent = len(prefix)
+	}
+
+	var sb strings.Builder
+	line := prefix + parts[0]
+	for _, p := range parts[1:] {
+		if width > 0 && len(line)+1+len(p) > width && len(line) > indent {
+			sb.WriteString(line)
+			sb.WriteByte('\n')
+			line = strings.Repeat(" ", indent) + p
+			continue
+		}
+		line += " " + p
+	}
+	sb.WriteString(line)
+
+	return sb.String()
+}
+
+// hasVariadic reports whether the last positional argument is variadic.
+func (cmd *Command) hasVariadic() bool {
+	n := len(cmd.positionals)
+	return n > 0 && cmd.positionals[n-1].variadic
+}
@@ -0,0 +1,306 @@
+package pflagx
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWrapSynopsis(t *testing.T) {
+	tests := []struct {
+		name    string
+		cmdName string
+		width   int
+		want    string
+	}{
+		{
+			name:    "no wrapping",
+			cmdName: "app",
+			want:    "Usage: app [flags] [--json | --yaml | --text] <source> <destination> [filter]",
+		},
+		{
+			name:    "narrow width",
+			cmdName: "app",
+			width:   40,
+			want: "Usage: app [flags]\n" +
+				"           [--json | --yaml | --text]\n" +
+				"           <source> <destination>\n" +
+				"           [filter]",
+		},
+		{
+			name:    "long program name",
+			cmdName: "a-very-long-program-name",
+			width:   40,
+			want: "Usage: a-very-long-program-name [flags]\n" +
+				"       [--json | --yaml | --text]\n" +
+				"       <source> <destination> [filter]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := New()
+			cmd.Name = tt.cmdName
+			fs := cmd.NewFlagSet("Output")
+			fs.Bool("json", false, "JSON output")
+			fs.Bool("yaml", false, "YAML output")
+			fs.Bool("text", false, "Text output")
+			cmd.MarkFlagsMutuallyExclusive("json", "yaml", "text")
+			cmd.AddPositional("source", true)
+			cmd.AddPositional("destination", true)
+			cmd.AddPositional("filter", false)
+
+			if got := cmd.wrapSynopsis("Usage: ", tt.width); got != tt.want {
+				t.Errorf("wrapSynopsis() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSynopsisWithoutFlags(t *testing.T) {
+	tests := []struct {
+		name   string
+		define func(cmd *Command)
+		want   string
+	}{
+		{
+			name:   "no flags",
+			define: func(*Command) {},
+			want:   "app <source> <destination>",
+		},
+		{
+			name: "hidden flags only",
+			define: func(cmd *Command) {
+				fs := cmd.NewFlagSet("Internal")
+				fs.Bool("debug", false, "Debug output")
+				fs.MarkHidden("debug")
+			},
+			want: "app <source> <destination>",
+		},
+		{
+			name: "visible flag",
+			define: func(cmd *Command) {
+				cmd.NewFlagSet("General").Bool("verbose", false, "Verbose output")
+			},
+			want: "app [flags] <source> <destination>",
+		},
+		{
+			name:   "version flag",
+			define: func(cmd *Command) { cmd.Version = "v1.0.0" },
+			want:   "app [flags] <source> <destination>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := New()
+			cmd.Name = "app"
+			cmd.AddPositional("source", true)
+			cmd.AddPositional("destination", true)
+			tt.define(cmd)
+
+			if got := cmd.Synopsis(); got != tt.want {
+				t.Errorf("Synopsis() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUsageWithoutFlags(t *testing.T) {
+	var out bytes.Buffer
+	cmd := newTestCommand(&out)
+	cmd.ShowSynopsis = true
+	cmd.AddPositional("source", true)
+	cmd.AddPositional("destination", true)
+
+	want := "app\nUsage: app <source> <destination>\n"
+	if got := cmd.UsageString(); got != want {
+		t.Errorf("UsageString() = %q, want %q", got, want)
+	}
+}
+
+func TestValidatePositionals(t *testing.T) {
+	tests := []struct {
+		name     string
+		variadic bool
+		args     []string
+		wantErr  string
+	}{
+		{name: "required only", args: []string{"a.txt", "b.txt"}},
+		{name: "with optional", args: []string{"a.txt", "b.txt", "*.go"}},
+		{name: "no arguments", wantErr: "missing required argument <source>"},
+		{name: "missing destination", args: []string{"a.txt"}, wantErr: "missing required argument <destination>"},
+		{
+			name:    "too many",
+			args:    []string{"a.txt", "b.txt", "*.go", "extra"},
+			wantErr: "too many arguments: expected at most 3, got 4",
+		},
+		{name: "variadic", variadic: true, args: []string{"a.txt", "b.txt", "*.go", "extra", "more"}},
+		{name: "variadic without values", variadic: true, args: []string{"a.txt", "b.txt", "*.go"}},
+		{name: "variadic missing required", variadic: true, args: []string{"a.txt"}, wantErr: "missing required argument <destination>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := newTestCommand(&out)
+			cmd.AddPositional("source", true)
+			cmd.AddPositional("destination", true)
+			cmd.AddPositional("filter", false)
+			if tt.variadic {
+				cmd.AddPositionalVariadic("rest")
+			}
+			if err := cmd.ParseArgs(tt.args); err != nil {
+				t.Fatalf("ParseArgs() error = %v", err)
+			}
+
+			err := cmd.ValidatePositionals()
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("ValidatePositionals() error = %v", err)
+			case tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr):
+				t.Errorf("ValidatePositionals() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPositional(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want map[string]string
+	}{
+		{
+			name: "all given",
+			args: []string{"a.txt", "b.txt", "*.go", "x", "y"},
+			want: map[string]string{"source": "a.txt", "destination": "b.txt", "filter": "*.go", "rest": "x"},
+		},
+		{
+			name: "optional missing",
+			args: []string{"a.txt", "b.txt"},
+			want: map[string]string{"source": "a.txt", "destination": "b.txt", "filter": "", "rest": ""},
+		},
+		{
+			name: "after flags",
+			args: []string{"-v", "a.txt", "--", "-b.txt"},
+			want: map[string]string{"source": "a.txt", "destination": "-b.txt"},
+		},
+		{
+			name: "unknown name",
+			args: []string{"a.txt", "b.txt"},
+			want: map[string]string{"unknown": ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := newTestCommand(&out)
+			cmd.NewFlagSet("General").BoolP("verbose", "v", false, "Verbose output")
+			cmd.AddPositional("source", true)
+			cmd.AddPositional("destination", true)
+			cmd.AddPositional("filter", false)
+			cmd.AddPositionalVariadic("rest")
+			if err := cmd.ParseArgs(tt.args); err != nil {
+				t.Fatalf("ParseArgs() error = %v", err)
+			}
+
+			for name, want := range tt.want {
+				if got := cmd.Positional(name); got != want {
+					t.Errorf("Positional(%q) = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSynopsis(t *testing.T) {
+	tests := []struct {
+		name   string
+		define func(cmd *Command)
+		want   string
+	}{
+		{
+			name:   "no positionals",
+			define: func(*Command) {},
+			want:   "app [flags]",
+		},
+		{
+			name: "required and optional",
+			define: func(cmd *Command) {
+				cmd.AddPositional("source", true)
+				cmd.AddPositional("destination", true)
+				cmd.AddPositional("filter", false)
+			},
+			want: "app [flags] <source> <destination> [filter]",
+		},
+		{
+			name: "variadic",
+			define: func(cmd *Command) {
+				cmd.AddPositional("source", true)
+				cmd.AddPositionalVariadic("filter")
+			},
+			want: "app [flags] <source> [filter...]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := New()
+			cmd.Name = "app"
+			cmd.NewFlagSet("General").Bool("verbose", false, "Verbose output")
+			tt.define(cmd)
+
+			if got := cmd.Synopsis(); got != tt.want {
+				t.Errorf("Synopsis() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShowSynopsis(t *testing.T) {
+	tests := []struct {
+		name         string
+		showSynopsis bool
+		want         string
+	}{
+		{
+			name: "hidden",
+			want: "app\n" +
+				"Copy files.\n" +
+				"\n" +
+				"General:\n" +
+				"      --verbose    Verbose output\n",
+		},
+		{
+			name:         "shown",
+			showSynopsis: true,
+			want: "app\n" +
+				"Copy files.\n" +
+				"\n" +
+				"Usage: app [flags] <source> [filter...]\n" +
+				"\n" +
+				"General:\n" +
+				"      --verbose    Verbose output\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := newTestCommand(&out)
+			cmd.Description = "Copy files."
+			cmd.ShowSynopsis = tt.showSynopsis
+			cmd.NewFlagSet("General").Bool("verbose", false, "Verbose output")
+			cmd.AddPositional("source", true)
+
+			// Positionals added after the first render are reflected
+			cmd.UsageString()
+			cmd.AddPositionalVariadic("filter")
+
+			if got := cmd.UsageString(); got != tt.want {
+				t.Errorf("UsageString() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package pflagx
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// maxResponseFileDepth is the maximum nesting of response files, which
+// prevents response files that reference each other from looping forever.
+const maxResponseFileDepth = 10
+
+// expandResponseFiles returns args with each "@file" argument replaced by the
+// arguments read from file, recursively. "@@" at the start of an argument
+// stands for a literal "@". Arguments after "--" are left alone.
+func expandResponseFiles(args []string, depth int) ([]string, error) {
+	expanded := make([]string, 0, len(args))
+
+	for i, arg := range args {
+		if arg == "--" {
+			return append(expanded, args[i:]...), nil
+		}
+
+		if len(arg) < 2 || arg[0] != '@' {
+			expanded = append(expanded, arg)
+			continue
+		}
+
+		if arg[1] == '@' {
+			expanded = append(expanded, arg[1:])
+			continue
+		}
+
+		if depth >= maxResponseFileDepth {
+			return nil, fmt.Errorf("response file %s: nested too deeply", arg[1:])
+		}
+
+		data, err := os.ReadFile(arg[1:])
+		if err != nil {
+			return nil, fmt.Errorf("response file: %w", err)
+		}
+
+		tokens, err := splitResponseFile(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("response file %s: %w", arg[1:], err)
+		}
+
+		tokens, err = expandResponseFiles(tokens, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		expanded = append(expanded, tokens...)
+	}
+
+	return expanded, nil
+}
+
+// splitResponseFile splits the content of a response file into arguments
+// separated by whitespace. Single quotes preserve their content literally,
+// double quotes allow escaping '"' and '\' with a backslash, and a backslash
+// outside of quotes escapes the next character.
+func splitResponseFile(s string) ([]string, error) {
+	var tokens []string
+	token := strings.Builder{}
+	inToken := false
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if inToken {
+				tokens = append(tokens, token.String())
+				token.Reset()
+				inToken = false
+			}
+
+		case c == '\'':
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end < 0 {
+				return nil, errors.New("unterminated single quote")
+			}
+			token.WriteString(s[i+1 : i+1+end])
+			i += end + 1
+			inToken = true
+
+		case c == '"':
+			i++
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) && (s[i+1] == '"' || s[i+1] == '\\') {
+					i++
+				}
+				token.WriteByte(s[i])
+			}
+			if i >= len(s) {
+				return nil, errors.New("unterminated double quote")
+			}
+			inToken = true
+
+		case c == '\\' && i+1 < len(s):
+			i++
+			token.WriteByte(s[i])
+			inToken = true
+
+		default:
+			token.WriteByte(c)
+			inToken = true
+		}
+	}
+
+	if inToken {
+		tokens = append(tokens, token.String())
+	}
+
+	return tokens, nil
+}
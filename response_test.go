@@ -0,0 +1,139 @@
+package pflagx
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestSplitResponseFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		wantErr string
+	}{
+		{name: "empty", input: ""},
+		{name: "whitespace", input: "-v\t--name  app\r\n\nout.txt\n", want: []string{"-v", "--name", "app", "out.txt"}},
+		{name: "single quotes", input: `--path 'my dir/a "b" \n'`, want: []string{"--path", `my dir/a "b" \n`}},
+		{name: "double quotes", input: `--path "my dir/\"b\" \\ \n"`, want: []string{"--path", `my dir/"b" \ \n`}},
+		{name: "adjacent quotes", input: `--name=a'b c'"d e"`, want: []string{"--name=ab cd e"}},
+		{name: "empty quotes", input: `'' ""`, want: []string{"", ""}},
+		{name: "backslash", input: `my\ dir \'`, want: []string{"my dir", "'"}},
+		{name: "unterminated single quote", input: "'abc", wantErr: "unterminated single quote"},
+		{name: "unterminated double quote", input: `"abc`, wantErr: "unterminated double quote"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := splitResponseFile(tt.input)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("splitResponseFile() error = %v", err)
+			case tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr):
+				t.Fatalf("splitResponseFile() error = %v, want %q", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("splitResponseFile() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResponseFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"args.txt":   "--name 'my app' -v\nin.txt",
+		"nested.txt": "@" + filepath.Join(dir, "args.txt") + " out.txt",
+		"loop.txt":   "@" + filepath.Join(dir, "loop.txt"),
+		"bad.txt":    "'unterminated",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	file := func(name string) string { return "@" + filepath.Join(dir, name) }
+
+	tests := []struct {
+		name     string
+		disabled bool
+		args     []string
+		wantName string
+		wantArgs []string
+		wantErr  string
+	}{
+		{
+			name:     "expanded",
+			args:     []string{file("args.txt"), "out.txt"},
+			wantName: "my app",
+			wantArgs: []string{"in.txt", "out.txt"},
+		},
+		{
+			name:     "nested",
+			args:     []string{file("nested.txt")},
+			wantName: "my app",
+			wantArgs: []string{"in.txt", "out.txt"},
+		},
+		{
+			name:     "escaped",
+			args:     []string{"@@user", "@"},
+			wantArgs: []string{"@user", "@"},
+		},
+		{
+			name:     "after terminator",
+			args:     []string{"--", file("args.txt"), "@@user"},
+			wantArgs: []string{file("args.txt"), "@@user"},
+		},
+		{
+			name:     "disabled",
+			disabled: true,
+			args:     []string{file("args.txt")},
+			wantArgs: []string{file("args.txt")},
+		},
+		{
+			name:    "cycle",
+			args:    []string{file("loop.txt")},
+			wantErr: "response file " + filepath.Join(dir, "loop.txt") + ": nested too deeply",
+		},
+		{
+			name:    "syntax error",
+			args:    []string{file("bad.txt")},
+			wantErr: "response file " + filepath.Join(dir, "bad.txt") + ": unterminated single quote",
+		},
+		{
+			name:    "missing file",
+			args:    []string{file("missing.txt")},
+			wantErr: "response file: open " + filepath.Join(dir, "missing.txt") + ": no such file or directory",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := newTestCommand(&out)
+			cmd.ResponseFiles = !tt.disabled
+			fs := cmd.NewFlagSet("General")
+			name := fs.String("name", "", "Name of the project")
+			fs.BoolP("verbose", "v", false, "Verbose output")
+
+			err := cmd.ParseArgs(tt.args)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("ParseArgs() error = %v", err)
+			case tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr):
+				t.Fatalf("ParseArgs() error = %v, want %q", err, tt.wantErr)
+			case tt.wantErr != "":
+				return
+			}
+			if *name != tt.wantName {
+				t.Errorf("--name = %q, want %q", *name, tt.wantName)
+			}
+			if !slices.Equal(cmd.Args(), tt.wantArgs) {
+				t.Errorf("Args() = %q, want %q", cmd.Args(), tt.wantArgs)
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package pflagx
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// AddCommand adds sub as a subcommand named name. When the first positional
+// argument given to Parse is name, it is removed and the arguments are parsed
+// by sub instead. The flags of the Command remain available to sub, and the
+// subcommands are listed in help output under "Commands". A subcommand added
+// with the name of an existing one replaces it.
+func (cmd *Command) AddCommand(name string, sub *Command) {
+	sub.Name = name
+	sub.parent = cmd
+
+	for i, c := range cmd.commands {
+		if c.Name == name {
+			cmd.commands[i] = sub
+			return
+		}
+	}
+
+	cmd.commands = append(cmd.commands, sub)
+}
+
+// Subcommand returns the subcommand selected during the last parse, or nil
+// if no subcommand was given.
+func (cmd *Command) Subcommand() *Command {
+	return cmd.subcommand
+}
+
+// lookupCommand returns the subcommand with the given name, or nil if no
+// such subcommand exists.
+func (cmd *Command) lookupCommand(name string) *Command {
+	for _, c := range cmd.commands {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// findCommand returns the subcommand named by the first positional argument
+// in args along with its index, or nil if there is no such subcommand.
+func (cmd *Command) findCommand(args []string) (*Command, int) {
+	if len(cmd.commands) == 0 {
+		return nil, -1
+	}
+
+	i := firstPositional(cmd.mergeFlagSets(), args)
+	if i < 0 {
+		return nil, -1
+	}
+
+	sub := cmd.lookupCommand(args[i])
+	if sub == nil {
+		return nil, -1
+	}
+
+	return sub, i
+}
+
+// checkCommand returns an error if the first positional argument in args
+// should name a subcommand but does not, as decided by UnknownCommandFunc or
+// unknownCommand. It only applies to a Command that has visible subcommands
+// but no positional arguments, and "help" is always accepted.
+func (cmd *Command) checkCommand(fs *pflag.FlagSet, args []string) error {
+	if len(cmd.visibleCommands()) == 0 || len(cmd.positionals) > 0 {
+		return nil
+	}
+
+	i := firstPositional(fs, args)
+	if i < 0 || args[i] == "help" {
+		return nil
+	}
+
+	if cmd.UnknownCommandFunc != nil {
+		return cmd.UnknownCommandFunc(args[i])
+	}
+	return cmd.unknownCommand(args[i])
+}
+
+// unknownCommand returns the error for the unknown subcommand name, with the
+// closest visible subcommand as suggestion, e.g.
+// "unknown command: srve (did you mean serve?)".
+func (cmd *Command) unknownCommand(name string) error {
+	var names []string
+	for _, c := range cmd.visibleCommands() {
+		names = append(names, c.Name)
+	}
+
+	if s := suggest(name, names); s != "" {
+		return fmt.Errorf("unknown command: %s (did you mean %s?)", name, s)
+	}
+	return fmt.Errorf("unknown command: %s", name)
+}
+
+// helpCommand handles the "help" positional argument followed by args, which
+// holds at most one name. It returns ErrHelp after writing the usage of the
+// subcommand, the FlagSet or the help topic with that name to out, or the
+// help of the Command if there is no name. An unknown name is an error that
+// suggests the closest one. The bare "help" and unknown names are only
+// handled by a Command with visible subcommands and no positional arguments,
+// or with help topics; it returns nil otherwise.
+func (cmd *Command) helpCommand(out io.Writer, args []string, silent bool) error {
+	handled := len(cmd.visibleCommands()) > 0 && len(cmd.positionals) == 0 || len(cmd.topics) > 0
+
+	if len(args) == 0 {
+		if !handled {
+			return nil
+		}
+		if !silent {
+			cmd.showUsage()
+		}
+		return ErrHelp
+	}
+
+	name := args[0]
+	if sub := cmd.lookupCommand(name); sub != nil {
+		io.WriteString(out, sub.UsageString())
+		return ErrHelp
+	}
+
+	if fs := cmd.lookupFlagSet(name); fs != nil {
+		io.WriteString(out, cmd.flagSetString(fs))
+		return ErrHelp
+	}
+
+	if cmd.lookupTopic(name) != nil {
+		if err := cmd.printTopic(out, name); err != nil {
+			return err
+		}
+		return ErrHelp
+	}
+
+	if !handled {
+		return nil
+	}
+	return cmd.unknownHelpTopic(name)
+}
+
+// unknownHelpTopic returns the error for the unknown name given to "help",
+// with the closest visible subcommand, FlagSet or help topic as suggestion.
+func (cmd *Command) unknownHelpTopic(name string) error {
+	var names []string
+	for _, c := range cmd.visibleCommands() {
+		names = append(names, c.Name)
+	}
+	for _, fs := range cmd.flagSets {
+		if fs.Name != "" {
+			names = append(names, fs.Name)
+		}
+	}
+	for _, t := range cmd.topics {
+		names = append(names, t.name)
+	}
+
+	if s := suggest(name, names); s != "" {
+		return fmt.Errorf("unknown help topic %q (did you mean %q?)", name, s)
+	}
+	return fmt.Errorf("unknown help topic %q", name)
+}
+
+// firstPositional returns the index of the first positional argument in
+// args, skipping the flags of fs along with their values, or -1 if there is
+// no positional argument before the end of the flags.
+func firstPositional(fs *pflag.FlagSet, args []string) int {
+	i := -1
+	scanArgs(fs, args, nil, func(t argToken) bool {
+		if t.kind == argPositional {
+			i = t.index
+		}
+		return t.kind != argPositional && t.kind != argTerminator
+	})
+
+	return i
+}
+
+// enabledFlagSets returns the enabled FlagSets of the Command followed by
+// those of its parents, whose flags remain available to subcommands.
+func (cmd *Command) enabledFlagSets() []*FlagSet {
+	var flagSets []*FlagSet
+	for c := cmd; c != nil; c = c.parent {
+		versionGroup := c.placeVersionFlag()
+		for _, fs := range c.flagSets {
+			if fs.Enabled {
+				flagSets = append(flagSets, fs)
+			}
+		}
+		if versionGroup != nil {
+			flagSets = append(flagSets, versionGroup)
+		}
+	}
+	return flagSets
+}
+
+// visibleCommands returns the subcommands that are not Hidden.
+func (cmd *Command) visibleCommands() []*Command {
+	var commands []*Command
+	for _, c := range cmd.commands {
+		if !c.Hidden {
+			commands = append(commands, c)
+		}
+	}
+	return commands
+}
+
+// commandsString returns the "Commands" section listing every visible
+// subcommand with the first line of its description, aligned the same way
+// as flags. It returns an empty string if there is no visible subcommand.
+func (cmd *Command) commandsString() string {
+	commands := cmd.visibleCommands()
+	if len(commands) == 0 {
+		return ""
+	}
+
+	var maxNameLen int
+	for _, c := range commands {
+		maxNameLen = max(maxNameLen, len(c.Name))
+	}
+
+	sb := strings.Builder{}
+	sb.WriteString("Commands:\n")
+
+	indentation := strings.Repeat(" ", cmd.Indentation)
+	for _, c := range commands {
+		sb.WriteString(indentation)
+		sb.WriteString(c.Name)
+		if summary, _, _ := strings.Cut(c.Description, "\n"); summary != "" {
+			sb.WriteString(strings.Repeat(" ", maxNameLen-len(c.Name)+cmd.Padding))
+			sb.WriteString(summary)
+		}
+		sb.WriteByte('\n')
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,172 @@
+package pflagx
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestUnknownCommand(t *testing.T) {
+	errPlugin := errors.New("plugin not found")
+
+	tests := []struct {
+		name     string
+		fn       func(name string) error
+		args     []string
+		wantErr  string
+		wantArgs []string
+	}{
+		{
+			name:    "typo",
+			args:    []string{"srve"},
+			wantErr: "unknown command: srve (did you mean serve?)",
+		},
+		{
+			name:    "unknown",
+			args:    []string{"xyzzy"},
+			wantErr: "unknown command: xyzzy",
+		},
+		{
+			name:    "func error",
+			fn:      func(string) error { return errPlugin },
+			args:    []string{"xyzzy"},
+			wantErr: errPlugin.Error(),
+		},
+		{
+			name:     "func accepts",
+			fn:       func(string) error { return nil },
+			args:     []string{"xyzzy", "arg"},
+			wantArgs: []string{"xyzzy", "arg"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := newTestCommand(&out)
+			cmd.UnknownCommandFunc = tt.fn
+			cmd.AddCommand("serve", New())
+			cmd.AddCommand("status", New())
+
+			err := cmd.ParseArgs(tt.args)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("ParseArgs() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseArgs() error = %v", err)
+			}
+			if got := cmd.Args(); !slices.Equal(got, tt.wantArgs) {
+				t.Errorf("Args() = %q, want %q", got, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestSubcommandDispatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		wantCommand string
+		wantVerbose bool
+		wantPort    int
+		wantArgs    []string
+	}{
+		{
+			name:        "no subcommand",
+			args:        []string{"-v"},
+			wantVerbose: true,
+			wantPort:    8080,
+		},
+		{
+			name:        "subcommand flags",
+			args:        []string{"serve", "--port", "9000", "extra"},
+			wantCommand: "serve",
+			wantPort:    9000,
+			wantArgs:    []string{"extra"},
+		},
+		{
+			name:        "global flag before the subcommand",
+			args:        []string{"-v", "serve"},
+			wantCommand: "serve",
+			wantVerbose: true,
+			wantPort:    8080,
+		},
+		{
+			name:        "global flag after the subcommand",
+			args:        []string{"migrate", "--verbose"},
+			wantCommand: "migrate",
+			wantVerbose: true,
+			wantPort:    8080,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := newTestCommand(&out)
+			verbose := cmd.NewFlagSet("Global").BoolP("verbose", "v", false, "Verbose output")
+			serve := New()
+			port := serve.NewFlagSet("Server").Int("port", 8080, "Port to listen on")
+			cmd.AddCommand("serve", serve)
+			cmd.AddCommand("migrate", New())
+
+			if err := cmd.ParseArgs(tt.args); err != nil {
+				t.Fatalf("ParseArgs() error = %v", err)
+			}
+
+			sub := cmd.Subcommand()
+			switch {
+			case tt.wantCommand == "" && sub != nil:
+				t.Fatalf("Subcommand() = %q, want nil", sub.Name)
+			case tt.wantCommand != "" && (sub == nil || sub.Name != tt.wantCommand):
+				t.Fatalf("Subcommand() = %v, want %q", sub, tt.wantCommand)
+			}
+			if *verbose != tt.wantVerbose || *port != tt.wantPort {
+				t.Errorf("verbose = %v, port = %d, want %v and %d", *verbose, *port, tt.wantVerbose, tt.wantPort)
+			}
+
+			args := cmd.Args()
+			if sub != nil {
+				args = sub.Args()
+			}
+			if !slices.Equal(args, tt.wantArgs) {
+				t.Errorf("Args() = %q, want %q", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestSubcommandHelp(t *testing.T) {
+	var out bytes.Buffer
+	cmd := newTestCommand(&out)
+	cmd.NewFlagSet("Global").BoolP("verbose", "v", false, "Verbose output")
+	serve := New()
+	serve.Description = "Start the server.\nListens until interrupted."
+	serve.NewFlagSet("Server").Int("port", 8080, "Port to listen on")
+	cmd.AddCommand("serve", serve)
+	migrate := New()
+	migrate.Description = "Migrate the database."
+	cmd.AddCommand("migrate", migrate)
+
+	want := "app\n" +
+		"Commands:\n" +
+		"  serve      Start the server.\n" +
+		"  migrate    Migrate the database.\n" +
+		"\n" +
+		"Global:\n" +
+		"  -v, --verbose    Verbose output\n"
+	if got := cmd.UsageString(); got != want {
+		t.Errorf("UsageString() =\n%s\nwant:\n%s", got, want)
+	}
+
+	if err := cmd.ParseArgs([]string{"help", "serve"}); !errors.Is(err, ErrHelp) {
+		t.Fatalf("ParseArgs(help serve) error = %v, want ErrHelp", err)
+	}
+	if want := serve.UsageString(); out.String() != want {
+		t.Errorf("help serve wrote %q, want %q", out.String(), want)
+	}
+}
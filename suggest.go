@@ -0,0 +1,134 @@
+package pflagx
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// maxSuggestionDistance is the maximum edit distance between a value and
+// a candidate for the candidate to be suggested.
+const maxSuggestionDistance = 2
+
+// suggest returns the candidate closest to s by edit distance, or an empty
+// string if no candidate is within maxSuggestionDistance. On a tie, the
+// first candidate wins.
+func suggest(s string, candidates []string) string {
+	best := ""
+	bestDistance := maxSuggestionDistance + 1
+
+	for _, c := range candidates {
+		if d := levenshtein(s, c); d < bestDistance {
+			best = c
+			bestDistance = d
+		}
+	}
+
+	return best
+}
+
+// levenshtein returns the edit distance between a and b, counting the
+// insertions, deletions and substitutions of runes needed to turn a into b.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
+
+// suggestFlag adds the closest long flag name to the unknown flag errors
+// returned by pflag, e.g. "unknown flag: --verbsoe (did you mean --verbose?)".
+// Hidden flags are candidates too. Other errors are returned as is.
+func (cmd *Command) suggestFlag(err error) error {
+	name, ok := strings.CutPrefix(err.Error(), "unknown flag: --")
+	if !ok {
+		return err
+	}
+
+	var candidates []string
+	for _, fs := range cmd.enabledFlagSets() {
+		fs.visitAllFlags(func(f *pflag.Flag) {
+			candidates = append(candidates, f.Name)
+		})
+	}
+
+	if s := suggest(name, candidates); s != "" {
+		return fmt.Errorf("%w (did you mean --%s?)", err, s)
+	}
+	return err
+}
+
+// expandPrefixes returns args with every long flag that is not a flag of fs
+// but the prefix of exactly one replaced by the full name of that flag. Only
+// the flags before the first positional argument are expanded unless
+// interspersed is set. It returns an error if such a prefix matches more
+// than one flag.
+func expandPrefixes(fs *pflag.FlagSet, args []string, interspersed bool) ([]string, error) {
+	var err error
+	lookup := func(name string) *pflag.Flag {
+		if f := fs.Lookup(name); f != nil || name == "" || name == "help" {
+			return f
+		}
+
+		var matches []*pflag.Flag
+		fs.VisitAll(func(c *pflag.Flag) {
+			if strings.HasPrefix(c.Name, name) {
+				matches = append(matches, c)
+			}
+		})
+
+		if len(matches) > 1 {
+			names := make([]string, len(matches))
+			for j, m := range matches {
+				names[j] = "--" + m.Name
+			}
+			err = fmt.Errorf("ambiguous flag --%s (matches %s)", name, strings.Join(names, ", "))
+		}
+		if len(matches) != 1 {
+			return nil
+		}
+		return matches[0]
+	}
+
+	expanded := slices.Clone(args)
+	scanArgs(fs, args, lookup, func(t argToken) bool {
+		switch t.kind {
+		case argPositional:
+			return interspersed
+		case argTerminator:
+			return false
+		case argLong:
+			if f := t.flags[0]; f.flag != nil && f.flag.Name != f.name {
+				expanded[t.index] = "--" + f.flag.Name
+				if f.hasValue {
+					expanded[t.index] += "=" + f.value
+				}
+			}
+		}
+		return err == nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return expanded, nil
+}
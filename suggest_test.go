@@ -0,0 +1,240 @@
+package pflagx
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "", b: "", want: 0},
+		{a: "json", b: "json", want: 0},
+		{a: "jsn", b: "json", want: 1},
+		{a: "jsno", b: "json", want: 2},
+		{a: "kitten", b: "sitting", want: 3},
+		{a: "", b: "yaml", want: 4},
+		{a: "héllo", b: "hello", want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"/"+tt.b, func(t *testing.T) {
+			if got := levenshtein(tt.a, tt.b); got != tt.want {
+				t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	candidates := []string{"text", "json", "yaml"}
+
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{s: "jsn", want: "json"},
+		{s: "yml", want: "yaml"},
+		{s: "txt", want: "text"},
+		{s: "markdown", want: ""},
+		{s: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			if got := suggest(tt.s, candidates); got != tt.want {
+				t.Errorf("suggest(%q) = %q, want %q", tt.s, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInvalidValueSuggestion(t *testing.T) {
+	tests := []struct {
+		name           string
+		value          string
+		wantSuggestion string
+		wantErr        string
+	}{
+		{
+			name:           "near miss",
+			value:          "jsn",
+			wantSuggestion: "json",
+			wantErr:        `invalid value "jsn" for --format; did you mean "json"?`,
+		},
+		{
+			name:    "far off",
+			value:   "markdown",
+			wantErr: `invalid value "markdown" for --format: must be one of "text", "json", "yaml"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := New()
+			cmd.NewFlagSet("Output").String("format", "text", "Output format")
+			if err := cmd.ParseSilent([]string{"--format", tt.value}); err != nil {
+				t.Fatalf("ParseSilent() error = %v", err)
+			}
+
+			err := cmd.ValidateChoice("format", "text", "json", "yaml")
+
+			var invalid *InvalidValueError
+			if !errors.As(err, &invalid) {
+				t.Fatalf("ValidateChoice() error = %v, want *InvalidValueError", err)
+			}
+			if invalid.Suggestion != tt.wantSuggestion {
+				t.Errorf("Suggestion = %q, want %q", invalid.Suggestion, tt.wantSuggestion)
+			}
+			if err.Error() != tt.wantErr {
+				t.Errorf("Error() = %q, want %q", err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUnknownFlagSuggestion(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{
+			name:    "typo",
+			args:    []string{"--verbsoe"},
+			wantErr: "unknown flag: --verbsoe (did you mean --verbose?)",
+		},
+		{
+			name:    "flag of another group",
+			args:    []string{"--db-hots", "db.example.com"},
+			wantErr: "unknown flag: --db-hots (did you mean --db-host?)",
+		},
+		{
+			name:    "hidden flag",
+			args:    []string{"--debgu"},
+			wantErr: "unknown flag: --debgu (did you mean --debug?)",
+		},
+		{
+			name:    "with a value",
+			args:    []string{"--verbos=true"},
+			wantErr: "unknown flag: --verbos (did you mean --verbose?)",
+		},
+		{
+			name:    "no close flag",
+			args:    []string{"--format"},
+			wantErr: "unknown flag: --format",
+		},
+		{
+			name:    "unknown shorthand",
+			args:    []string{"-x"},
+			wantErr: "unknown shorthand flag: 'x' in -x",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := New()
+			general := cmd.NewFlagSet("General")
+			general.BoolP("verbose", "v", false, "Verbose output")
+			general.Bool("debug", false, "Debug output")
+			general.MarkHidden("debug")
+			cmd.NewFlagSet("Database").String("db-host", "localhost", "Database host")
+
+			err := cmd.ParseSilent(tt.args)
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("ParseSilent() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAllowPrefixMatch(t *testing.T) {
+	tests := []struct {
+		name         string
+		disabled     bool
+		interspersed bool
+		args         []string
+		wantConfig   string
+		wantVerbose  bool
+		wantArgs     []string
+		wantErr      string
+	}{
+		{
+			name:        "unique prefixes",
+			args:        []string{"--verb", "--conf", "app.yaml"},
+			wantConfig:  "app.yaml",
+			wantVerbose: true,
+		},
+		{
+			name:       "prefix with value",
+			args:       []string{"--conf=app.yaml"},
+			wantConfig: "app.yaml",
+		},
+		{
+			name:    "ambiguous",
+			args:    []string{"--co", "auto"},
+			wantErr: "ambiguous flag --co (matches --color, --colors, --config)",
+		},
+		{
+			name: "exact match wins",
+			args: []string{"--color"},
+		},
+		{
+			name:     "after terminator",
+			args:     []string{"--", "--verb"},
+			wantArgs: []string{"--verb"},
+		},
+		{
+			name:     "after positional",
+			args:     []string{"file", "--verb"},
+			wantArgs: []string{"file", "--verb"},
+		},
+		{
+			name:         "after positional interspersed",
+			interspersed: true,
+			args:         []string{"file", "--verb"},
+			wantArgs:     []string{"file"},
+			wantVerbose:  true,
+		},
+		{
+			name:     "disabled",
+			disabled: true,
+			args:     []string{"--verb"},
+			wantErr:  "unknown flag: --verb",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := newTestCommand(&out)
+			cmd.AllowPrefixMatch = !tt.disabled
+			cmd.InterspersedArgs = tt.interspersed
+			fs := cmd.NewFlagSet("General")
+			verbose := fs.BoolP("verbose", "v", false, "Verbose output")
+			config := fs.String("config", "", "Config file")
+			fs.Bool("color", false, "Color output")
+			fs.Bool("colors", false, "Color output")
+
+			err := cmd.ParseArgs(tt.args)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("ParseArgs() error = %v", err)
+			case tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr):
+				t.Fatalf("ParseArgs() error = %v, want %q", err, tt.wantErr)
+			case tt.wantErr != "":
+				return
+			}
+			if *verbose != tt.wantVerbose || *config != tt.wantConfig {
+				t.Errorf("verbose = %v, config = %q, want %v and %q", *verbose, *config, tt.wantVerbose, tt.wantConfig)
+			}
+			if !slices.Equal(cmd.Args(), tt.wantArgs) {
+				t.Errorf("Args() = %q, want %q", cmd.Args(), tt.wantArgs)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package pflagx
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/pflag"
+)
+
+// TemplateFuncs are the functions available to help templates. Add them to
+// a template before parsing it:
+//
+//	tmpl := template.Must(template.New("help").Funcs(pflagx.TemplateFuncs).Parse(text))
+//
+// The functions are:
+//
+//	pad s n    s padded with spaces on the right to n characters
+//	flags g    the flags of the TemplateFlagSet g as a []TemplateFlag
+var TemplateFuncs = template.FuncMap{
+	"pad":   templatePad,
+	"flags": templateFlags,
+}
+
+// TemplateData is the data passed to Command.Template.
+type TemplateData struct {
+	// Name is the program name.
+	Name string
+
+	// Version is the program version.
+	Version string
+
+	// Description is the description of the program.
+	Description string
+
+	// FlagSets lists the FlagSets shown in help output, in display order.
+	FlagSets []TemplateFlagSet
+}
+
+// TemplateFlagSet describes a FlagSet in TemplateData.
+type TemplateFlagSet struct {
+	// Name is the name of the FlagSet.
+	Name string
+
+	// Description is the description of the FlagSet.
+	Description string
+
+	// Footer is the footer of the FlagSet.
+	Footer string
+
+	// Flags holds the flags of the FlagSet rendered and aligned as in the
+	// default help output, one per line.
+	Flags string
+
+	fs *FlagSet
+}
+
+// TemplateFlag describes a flag, as returned by the flags template function.
+type TemplateFlag struct {
+	// Name is the long name of the flag, without dashes.
+	Name string
+
+	// Shorthand is the shorthand letter of the flag, or an empty string.
+	Shorthand string
+
+	// Type is the type of the value of the flag, e.g. "string".
+	Type string
+
+	// Usage is the usage text of the flag.
+	Usage string
+
+	// Default is the default value of the flag, or the output of the
+	// DefaultFormatter of the FlagSet if set. It is empty if the default is
+	// not shown in help output, e.g. with HideDefaults.
+	Default string
+}
+
+// writeTemplate executes the Template with the data of the Command and writes
+// the result to out. Nothing is written if the execution fails.
+func (cmd *Command) writeTemplate(out io.Writer) error {
+	data := TemplateData{
+		Name:        cmd.Name,
+		Version:     cmd.Version,
+		Description: cmd.Description,
+	}
+
+	flagSets := cmd.shownFlagSets()
+	maxNameLen := cmd.globalMaxNameLength(flagSets, nil)
+	width := cmd.width()
+	colors := cmd.activeColors()
+
+	for _, fs := range flagSets {
+		cmd.layoutFlagSet(fs, maxNameLen, width, colors, nil)
+
+		data.FlagSets = append(data.FlagSets, TemplateFlagSet{
+			Name:        fs.Name,
+			Description: fs.Description,
+			Footer:      fs.Footer,
+			Flags:       fs.flagsString(nil),
+			fs:          fs,
+		})
+	}
+
+	buf := bytes.Buffer{}
+	if err := cmd.Template.Execute(&buf, data); err != nil {
+		return err
+	}
+
+	_, err := buf.WriteTo(out)
+	return err
+}
+
+// templatePad returns s padded with spaces on the right to n characters.
+func templatePad(s string, n int) string {
+	return s + strings.Repeat(" ", max(n-visibleLength(s), 0))
+}
+
+// templateFlags returns the flags of g shown in help output.
+func templateFlags(g TemplateFlagSet) []TemplateFlag {
+	if g.fs == nil {
+		return nil
+	}
+
+	var flags []TemplateFlag
+	g.fs.visitFlags(nil, func(f *pflag.Flag) {
+		flags = append(flags, TemplateFlag{
+			Name:      f.Name,
+			Shorthand: f.Shorthand,
+			Type:      f.Value.Type(),
+			Usage:     f.Usage,
+			Default:   g.fs.defaultValue(f),
+		})
+	})
+
+	return flags
+}
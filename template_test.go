@@ -0,0 +1,70 @@
+package pflagx
+
+import (
+	"bytes"
+	"testing"
+	"text/template"
+)
+
+func TestTemplate(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "rendered flags",
+			text: "{{.Name}} {{.Version}}: {{.Description}}\n" +
+				"{{range .FlagSets}}[{{.Name}}] {{.Description}}\n{{.Flags}}{{.Footer}}\n{{end}}",
+			want: "app v1.0.0: A test application.\n" +
+				"[General] \n" +
+				"  -v, --verbose    Verbose output\n" +
+				"      --version    Print the version and exit\n" +
+				"\n" +
+				"[Database] Database settings\n" +
+				"      --db-host    Database host (default: \"localhost\")\n" +
+				"See the manual\n",
+		},
+		{
+			name: "template functions",
+			text: "{{range .FlagSets}}{{range flags .}}{{pad .Name 10}}{{.Type}} {{.Default}}\n{{end}}{{end}}",
+			want: "verbose   bool \n" +
+				"version   bool \n" +
+				"db-host   string localhost\n",
+		},
+		{
+			name: "failing template",
+			text: "{{.Unknown}}",
+			want: "app v1.0.0\n" +
+				"A test application.\n" +
+				"\n" +
+				"General:\n" +
+				"  -v, --verbose    Verbose output\n" +
+				"      --version    Print the version and exit\n" +
+				"\n" +
+				"Database:\n" +
+				"  Database settings\n" +
+				"      --db-host    Database host (default: \"localhost\")\n" +
+				"  See the manual\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := newTestCommand(&out)
+			cmd.Version = "v1.0.0"
+			cmd.Description = "A test application."
+			cmd.NewFlagSet("General").BoolP("verbose", "v", false, "Verbose output")
+			db := cmd.NewFlagSet("Database")
+			db.Description = "Database settings"
+			db.Footer = "See the manual"
+			db.String("db-host", "localhost", "Database host")
+			cmd.Template = template.Must(template.New("help").Funcs(TemplateFuncs).Parse(tt.text))
+
+			if got := cmd.UsageString(); got != tt.want {
+				t.Errorf("UsageString() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
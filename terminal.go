@@ -0,0 +1,68 @@
+package pflagx
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// isTerminal returns whether w is a file connected to a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// detectWidth returns the width of the output in columns: the value of the
+// COLUMNS environment variable if it is a positive integer, else the width of
+// the terminal behind w, else DefaultWidth.
+func detectWidth(w io.Writer) int {
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		return columns
+	}
+
+	f, ok := w.(*os.File)
+	if !ok {
+		return DefaultWidth
+	}
+
+	width, _, err := term.GetSize(int(f.Fd()))
+	if err != nil || width <= 0 {
+		return DefaultWidth
+	}
+
+	return width
+}
+
+// colorLevel returns the color support of the terminal behind w, as detected
+// by envColorLevel, or 0 if w is not a terminal.
+func colorLevel(w io.Writer) int {
+	if !isTerminal(w) {
+		return 0
+	}
+	return envColorLevel()
+}
+
+// envColorLevel returns the color support of the terminal as detected from
+// the TERM and COLORTERM environment variables: 0 if TERM is empty or "dumb",
+// 3 for truecolor, 2 for 256 colors, and 1 for the 16 basic colors otherwise.
+func envColorLevel() int {
+	termName := os.Getenv("TERM")
+	if termName == "" || termName == "dumb" {
+		return 0
+	}
+
+	switch colorTerm := os.Getenv("COLORTERM"); {
+	case colorTerm == "truecolor" || colorTerm == "24bit":
+		return 3
+	case strings.Contains(termName, "256color"):
+		return 2
+	}
+	return 1
+}
@@ -0,0 +1,45 @@
+//go:build linux
+
+package pflagx
+
+import (
+	"os"
+	"strconv"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+// openTerminal returns the follower side of a new pseudo-terminal of
+// terminalWidth columns, or skips the test if none can be opened.
+func openTerminal(t *testing.T) *os.File {
+	t.Helper()
+
+	leader, err := os.OpenFile("/dev/ptmx", os.O_RDWR, 0)
+	if err != nil {
+		t.Skipf("cannot open a pseudo-terminal: %v", err)
+	}
+	t.Cleanup(func() { leader.Close() })
+
+	fd := int(leader.Fd())
+	if err := unix.IoctlSetPointerInt(fd, unix.TIOCSPTLCK, 0); err != nil {
+		t.Skipf("cannot unlock the pseudo-terminal: %v", err)
+	}
+	n, err := unix.IoctlGetInt(fd, unix.TIOCGPTN)
+	if err != nil {
+		t.Skipf("cannot get the pseudo-terminal number: %v", err)
+	}
+
+	follower, err := os.OpenFile("/dev/pts/"+strconv.Itoa(n), os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		t.Skipf("cannot open the pseudo-terminal: %v", err)
+	}
+	t.Cleanup(func() { follower.Close() })
+
+	ws := &unix.Winsize{Row: 24, Col: terminalWidth}
+	if err := unix.IoctlSetWinsize(int(follower.Fd()), unix.TIOCSWINSZ, ws); err != nil {
+		t.Skipf("cannot set the pseudo-terminal size: %v", err)
+	}
+
+	return follower
+}
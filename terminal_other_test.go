@@ -0,0 +1,15 @@
+//go:build !linux
+
+package pflagx
+
+import (
+	"os"
+	"testing"
+)
+
+// openTerminal skips the test, as pseudo-terminals are only opened on Linux.
+func openTerminal(t *testing.T) *os.File {
+	t.Helper()
+	t.Skip("pseudo-terminals are only opened on Linux")
+	return nil
+}
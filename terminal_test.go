@@ -0,0 +1,43 @@
+package pflagx
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// terminalWidth is the number of columns of the terminals returned by
+// openTerminal.
+const terminalWidth = 100
+
+func TestDetectWidth(t *testing.T) {
+	tests := []struct {
+		name     string
+		columns  string
+		terminal bool
+		want     int
+	}{
+		{name: "COLUMNS", columns: "120", want: 120},
+		{name: "COLUMNS over terminal", columns: "120", terminal: true, want: 120},
+		{name: "terminal", terminal: true, want: terminalWidth},
+		{name: "invalid COLUMNS", columns: "wide", terminal: true, want: terminalWidth},
+		{name: "negative COLUMNS", columns: "-5", want: DefaultWidth},
+		{name: "zero COLUMNS", columns: "0", want: DefaultWidth},
+		{name: "not a terminal", want: DefaultWidth},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("COLUMNS", tt.columns)
+
+			var w io.Writer = &bytes.Buffer{}
+			if tt.terminal {
+				w = openTerminal(t)
+			}
+
+			if got := detectWidth(w); got != tt.want {
+				t.Errorf("detectWidth() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+# bash completion for app
+
+_app_completion()
+{
+    local cur prev
+    cur="${COMP_WORDS[COMP_CWORD]}"
+    prev="${COMP_WORDS[COMP_CWORD-1]}"
+
+    case "$prev" in
+        --config|-c)
+            COMPREPLY=($(compgen -d -- "$cur") $(compgen -f -X '!*.yaml' -- "$cur") $(compgen -f -X '!*.yml' -- "$cur"))
+            return
+            ;;
+        --log)
+            COMPREPLY=($(compgen -f -- "$cur"))
+            return
+            ;;
+    esac
+
+    if [[ "$cur" == -* ]]; then
+        COMPREPLY=($(compgen -W "--config -c --log" -- "$cur"))
+        return
+    fi
+
+    COMPREPLY=($(compgen -f -- "$cur"))
+}
+
+complete -o filenames -F _app_completion app
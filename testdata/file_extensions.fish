@@ -0,0 +1,4 @@
+# fish completion for app
+
+complete -c app -l config -s c -r -f -a '(__fish_complete_suffix .yaml; __fish_complete_suffix .yml)' -d 'Config file'
+complete -c app -l log -r -F -d 'Log file'
@@ -0,0 +1,6 @@
+# fish completion for my-app
+
+complete -c my-app -l verbose -s v -d 'Verbose output'
+complete -c my-app -l output -s o -r -d 'Output file.'
+complete -c my-app -l db-host -r -d 'Database server\'s hostname'
+complete -c my-app -l db-port -s p -r -d 'Database port'
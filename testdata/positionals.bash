@@ -0,0 +1,30 @@
+# bash completion for app
+
+_app_completion()
+{
+    local cur prev
+    cur="${COMP_WORDS[COMP_CWORD]}"
+    prev="${COMP_WORDS[COMP_CWORD-1]}"
+
+    if [[ "$cur" == -* ]]; then
+        COMPREPLY=($(compgen -W "--verbose -v" -- "$cur"))
+        return
+    fi
+
+    local i n=0
+    for ((i = 1; i < COMP_CWORD; i++)); do
+        case "${COMP_WORDS[i]}" in
+            -*)
+                ;;
+            *)
+                ((n++))
+                ;;
+        esac
+    done
+
+    if ((n < 2)); then
+        COMPREPLY=($(compgen -f -- "$cur"))
+    fi
+}
+
+complete -o filenames -F _app_completion app
@@ -0,0 +1,23 @@
+# fish completion for app
+
+function __fish_app_positionals
+    set -l words (commandline -opc)
+    set -e words[1]
+    set -l n 0
+    set -l skip 0
+    for w in $words
+        if test $skip -eq 1
+            set skip 0
+            continue
+        end
+        switch $w
+            case '-*'
+            case '*'
+                set n (math $n + 1)
+        end
+    end
+    echo $n
+end
+complete -c app -n 'test (__fish_app_positionals) -ge 2' -f
+
+complete -c app -l verbose -s v -d 'Verbose output'
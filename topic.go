@@ -0,0 +1,76 @@
+package pflagx
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// topic is a free-form help article attached to a Command.
+type topic struct {
+	name  string
+	title string
+	body  string
+}
+
+// AddTopic adds a help topic to the Command. Topics are listed in help output
+// under "Additional Help Topics", and "help <name>" on the command line prints
+// the body of the topic.
+func (cmd *Command) AddTopic(name, title, body string) {
+	cmd.topics = append(cmd.topics, &topic{
+		name:  name,
+		title: title,
+		body:  body,
+	})
+}
+
+// lookupTopic returns the topic with the given name, or nil if no such topic
+// exists.
+func (cmd *Command) lookupTopic(name string) *topic {
+	for _, t := range cmd.topics {
+		if t.name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// printTopic writes the body of the named topic to w.
+func (cmd *Command) printTopic(w io.Writer, name string) error {
+	t := cmd.lookupTopic(name)
+	if t == nil {
+		return fmt.Errorf("unknown help topic %q", name)
+	}
+
+	_, err := io.WriteString(w, strings.TrimSuffix(t.body, "\n")+"\n")
+	return err
+}
+
+// topicsString returns the "Additional Help Topics" section listing
+// every topic with its title, aligned the same way as flags.
+func (cmd *Command) topicsString() string {
+	if len(cmd.topics) == 0 {
+		return ""
+	}
+
+	var maxNameLen int
+	for _, t := range cmd.topics {
+		maxNameLen = max(maxNameLen, len(t.name))
+	}
+
+	sb := strings.Builder{}
+	sb.WriteString("Additional Help Topics:\n")
+
+	indentation := strings.Repeat(" ", cmd.Indentation)
+	for _, t := range cmd.topics {
+		sb.WriteString(indentation)
+		sb.WriteString(t.name)
+		if t.title != "" {
+			sb.WriteString(strings.Repeat(" ", maxNameLen-len(t.name)+cmd.Padding))
+			sb.WriteString(t.title)
+		}
+		sb.WriteByte('\n')
+	}
+
+	return sb.String()
+}
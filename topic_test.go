@@ -0,0 +1,79 @@
+package pflagx
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// newTopicCommand returns a Command with a flag and two help topics.
+func newTopicCommand(out *bytes.Buffer) *Command {
+	cmd := newTestCommand(out)
+	cmd.NewFlagSet("General").BoolP("verbose", "v", false, "Verbose output")
+	cmd.AddTopic("config-format", "Format of the config file", "The config file is written in YAML.\n")
+	cmd.AddTopic("env", "Environment variables", "APP_VERBOSE enables verbose output.")
+	return cmd
+}
+
+func TestTopicsListed(t *testing.T) {
+	var out bytes.Buffer
+	cmd := newTopicCommand(&out)
+
+	want := "app\n" +
+		"General:\n" +
+		"  -v, --verbose    Verbose output\n" +
+		"\n" +
+		"Additional Help Topics:\n" +
+		"  config-format    Format of the config file\n" +
+		"  env              Environment variables\n"
+
+	if got := cmd.UsageString(); got != want {
+		t.Errorf("UsageString() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestHelpTopic(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "topic",
+			args: []string{"help", "config-format"},
+			want: "The config file is written in YAML.\n",
+		},
+		{
+			name: "topic without trailing newline",
+			args: []string{"help", "env"},
+			want: "APP_VERBOSE enables verbose output.\n",
+		},
+		{
+			name:    "unknown topic",
+			args:    []string{"help", "confg-format"},
+			wantErr: `unknown help topic "confg-format" (did you mean "config-format"?)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := newTopicCommand(&out)
+
+			err := cmd.ParseArgs(tt.args)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("ParseArgs() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if !errors.Is(err, ErrHelp) {
+				t.Fatalf("ParseArgs() error = %v, want ErrHelp", err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("ParseArgs() wrote %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
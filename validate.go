@@ -0,0 +1,190 @@
+package pflagx
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// MarkFlagsMutuallyExclusive declares that at most one of the named flags
+// may be set. The flags can belong to different FlagSets, and a flag can
+// belong to several exclusive groups. It returns an error if a flag does
+// not exist.
+func (cmd *Command) MarkFlagsMutuallyExclusive(names ...string) error {
+	if err := cmd.checkFlagsExist(names); err != nil {
+		return err
+	}
+
+	cmd.exclusiveGroups = append(cmd.exclusiveGroups, slices.Clone(names))
+	return nil
+}
+
+// MarkFlagsRequiredTogether declares that if any of the named flags is set,
+// all of them must be set. The flags can belong to different FlagSets. It
+// returns an error if a flag does not exist.
+func (cmd *Command) MarkFlagsRequiredTogether(names ...string) error {
+	if err := cmd.checkFlagsExist(names); err != nil {
+		return err
+	}
+
+	cmd.togetherGroups = append(cmd.togetherGroups, slices.Clone(names))
+	return nil
+}
+
+// validateFlags returns an error if the parsed flags do not satisfy
+// the constraints declared on them, including those declared on the parents
+// of a subcommand.
+func (cmd *Command) validateFlags() error {
+	if err := cmd.checkRequired(); err != nil {
+		return err
+	}
+	if err := cmd.checkMutuallyExclusive(); err != nil {
+		return err
+	}
+	return cmd.checkRequiredTogether()
+}
+
+// exclusiveGroupsShown returns the exclusive groups declared on the Command
+// and its parents, restricted to their visible flags. Groups left with fewer
+// than two flags are omitted.
+func (cmd *Command) exclusiveGroupsShown() [][]string {
+	var groups [][]string
+	for c := cmd; c != nil; c = c.parent {
+		for _, group := range c.exclusiveGroups {
+			var names []string
+			for _, name := range group {
+				if f := c.lookupFlag(name); f != nil && !f.Hidden && !slices.Contains(names, name) {
+					names = append(names, name)
+				}
+			}
+
+			if len(names) > 1 {
+				groups = append(groups, names)
+			}
+		}
+	}
+	return groups
+}
+
+// exclusiveFlags maps the name of each visible flag of an exclusive group
+// to the other visible flags it is mutually exclusive with, in order.
+func (cmd *Command) exclusiveFlags() map[string][]string {
+	exclusive := make(map[string][]string)
+	for _, group := range cmd.exclusiveGroupsShown() {
+		for _, name := range group {
+			for _, other := range group {
+				if other != name && !slices.Contains(exclusive[name], other) {
+					exclusive[name] = append(exclusive[name], other)
+				}
+			}
+		}
+	}
+	return exclusive
+}
+
+// checkFlagsExist returns an error if any of the named flags does not exist.
+func (cmd *Command) checkFlagsExist(names []string) error {
+	for _, name := range names {
+		if cmd.lookupFlag(name) == nil {
+			return fmt.Errorf("no such flag --%s", name)
+		}
+	}
+	return nil
+}
+
+// checkMutuallyExclusive returns an error if more than one flag of an
+// exclusive group was set, listing the flags that were set.
+func (cmd *Command) checkMutuallyExclusive() error {
+	for c := cmd; c != nil; c = c.parent {
+		for _, group := range c.exclusiveGroups {
+			var set []string
+			for _, name := range group {
+				if f := c.lookupFlag(name); f != nil && f.Changed {
+					set = append(set, flagDisplayName(f))
+				}
+			}
+
+			if len(set) > 1 {
+				return fmt.Errorf("flags %s are mutually exclusive", strings.Join(set, ", "))
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkRequired returns an error listing every required flag that was not set,
+// either on the command line, from the environment, or from a config file.
+func (cmd *Command) checkRequired() error {
+	var missing []string
+	for _, fs := range cmd.enabledFlagSets() {
+		fs.VisitAll(func(f *pflag.Flag) {
+			if isRequired(f) && !f.Changed && !cmd.envFlags[f.Name] && !cmd.fromConfig(f.Name) {
+				missing = append(missing, flagDisplayName(f))
+			}
+		})
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("required flag(s) %s not set", strings.Join(missing, ", "))
+}
+
+// checkRequiredTogether returns an error if some but not all flags of a
+// group that must be set together were set, listing the missing flags.
+func (cmd *Command) checkRequiredTogether() error {
+	for c := cmd; c != nil; c = c.parent {
+		for _, group := range c.togetherGroups {
+			var set, missing []string
+			for _, name := range group {
+				f := c.lookupFlag(name)
+				if f == nil {
+					continue
+				}
+
+				if f.Changed {
+					set = append(set, flagDisplayName(f))
+				} else {
+					missing = append(missing, flagDisplayName(f))
+				}
+			}
+
+			if len(set) > 0 && len(missing) > 0 {
+				return fmt.Errorf("flag(s) %s must be set when %s is set",
+					strings.Join(missing, ", "), strings.Join(set, ", "))
+			}
+		}
+	}
+
+	return nil
+}
+
+// fromConfig reports whether the named flag was set from a config file
+// loaded by the Command or one of its parents.
+func (cmd *Command) fromConfig(name string) bool {
+	for c := cmd; c != nil; c = c.parent {
+		if c.configFlags[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// isRequired returns whether the flag was marked as required.
+func isRequired(f *pflag.Flag) bool {
+	return flagAnnotation(f, annotationRequired) == "true"
+}
+
+// flagDisplayName returns the quoted long name of the flag, followed by its
+// shorthand if it has one, e.g. "--config/-c".
+func flagDisplayName(f *pflag.Flag) string {
+	name := "--" + f.Name
+	if f.Shorthand != "" {
+		name += "/-" + f.Shorthand
+	}
+	return `"` + name + `"`
+}
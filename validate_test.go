@@ -0,0 +1,312 @@
+package pflagx
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMutuallyExclusiveHelp(t *testing.T) {
+	tests := []struct {
+		name   string
+		hidden []string
+		want   string
+	}{
+		{
+			name: "all visible",
+			want: "app\n" +
+				"Usage: app [flags] [--json | --yaml | --text]\n" +
+				"\n" +
+				"Output:\n" +
+				"      --json    JSON output (mutually exclusive with --yaml, --text)\n" +
+				"      --yaml    YAML output (mutually exclusive with --json, --text)\n" +
+				"      --text    Text output (mutually exclusive with --json, --yaml)\n",
+		},
+		{
+			name:   "hidden flag left out",
+			hidden: []string{"text"},
+			want: "app\n" +
+				"Usage: app [flags] [--json | --yaml]\n" +
+				"\n" +
+				"Output:\n" +
+				"      --json    JSON output (mutually exclusive with --yaml)\n" +
+				"      --yaml    YAML output (mutually exclusive with --json)\n",
+		},
+		{
+			name:   "single visible flag",
+			hidden: []string{"yaml", "text"},
+			want: "app\n" +
+				"Usage: app [flags]\n" +
+				"\n" +
+				"Output:\n" +
+				"      --json    JSON output\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := newTestCommand(&out)
+			cmd.ShowSynopsis = true
+			fs := cmd.NewFlagSet("Output")
+			fs.Bool("json", false, "JSON output")
+			fs.Bool("yaml", false, "YAML output")
+			fs.Bool("text", false, "Text output")
+			if err := cmd.MarkFlagsMutuallyExclusive("json", "yaml", "text"); err != nil {
+				t.Fatalf("MarkFlagsMutuallyExclusive() error = %v", err)
+			}
+			for _, name := range tt.hidden {
+				fs.MarkHidden(name)
+			}
+
+			if got := cmd.UsageString(); got != tt.want {
+				t.Errorf("UsageString() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkRequired(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{
+			name:    "none set",
+			wantErr: `required flag(s) "--config/-c", "--db-host" not set`,
+		},
+		{
+			name:    "shorthand set",
+			args:    []string{"-c", "app.yaml"},
+			wantErr: `required flag(s) "--db-host" not set`,
+		},
+		{
+			name: "all set",
+			args: []string{"--config", "app.yaml", "--db-host", "localhost"},
+		},
+		{
+			name: "set to the default",
+			args: []string{"--config", "", "--db-host", "localhost"},
+		},
+		{
+			name:    "help",
+			args:    []string{"--help"},
+			wantErr: ErrHelp.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := New()
+			general := cmd.NewFlagSet("General")
+			general.StringP("config", "c", "", "Config file")
+			general.Bool("verbose", false, "Verbose output")
+			db := cmd.NewFlagSet("Database")
+			db.String("db-host", "", "Database host")
+			if err := general.MarkRequired("config"); err != nil {
+				t.Fatalf("MarkRequired() error = %v", err)
+			}
+			if err := db.MarkRequired("db-host"); err != nil {
+				t.Fatalf("MarkRequired() error = %v", err)
+			}
+
+			err := cmd.ParseSilent(tt.args)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("ParseSilent() error = %v, want nil", err)
+			case tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr):
+				t.Errorf("ParseSilent() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMarkRequiredUnknownFlag(t *testing.T) {
+	fs := New().NewFlagSet("General")
+	if err := fs.MarkRequired("config"); err == nil {
+		t.Error("MarkRequired() error = nil, want an error for an unknown flag")
+	}
+}
+
+func TestRequiredSuffix(t *testing.T) {
+	tests := []struct {
+		name   string
+		suffix string
+		custom bool
+		want   string
+	}{
+		{
+			name: "default",
+			want: "General:\n" +
+				"  -c, --config     Config file (required)\n" +
+				"      --db-host    Database host (required) (default: \"localhost\")\n",
+		},
+		{
+			name:   "custom",
+			suffix: " [mandatory]",
+			custom: true,
+			want: "General:\n" +
+				"  -c, --config     Config file [mandatory]\n" +
+				"      --db-host    Database host [mandatory] (default: \"localhost\")\n",
+		},
+		{
+			name:   "blank",
+			custom: true,
+			want: "General:\n" +
+				"  -c, --config     Config file\n" +
+				"      --db-host    Database host (default: \"localhost\")\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := New()
+			if tt.custom {
+				cmd.RequiredSuffix = tt.suffix
+			}
+			fs := cmd.NewFlagSet("General")
+			fs.StringP("config", "c", "", "Config file")
+			fs.String("db-host", "localhost", "Database host")
+			fs.String("token", "", "Hidden token")
+			fs.MarkRequired("config")
+			fs.MarkRequired("db-host")
+			fs.MarkRequired("token")
+			fs.MarkHidden("token")
+
+			got, err := cmd.RenderFlagSet("General")
+			if err != nil {
+				t.Fatalf("RenderFlagSet() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("RenderFlagSet() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkFlagsMutuallyExclusive(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "none set"},
+		{name: "one set", args: []string{"--json"}},
+		{name: "one of each group", args: []string{"--json", "--quiet"}},
+		{
+			name:    "two set",
+			args:    []string{"--yaml", "-j"},
+			wantErr: `flags "--json/-j", "--yaml" are mutually exclusive`,
+		},
+		{
+			name:    "second group",
+			args:    []string{"--quiet", "--verbose"},
+			wantErr: `flags "--verbose", "--quiet" are mutually exclusive`,
+		},
+		{
+			name:    "flag in two groups",
+			args:    []string{"--text", "--quiet"},
+			wantErr: `flags "--text", "--quiet" are mutually exclusive`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := New()
+			output := cmd.NewFlagSet("Output")
+			output.BoolP("json", "j", false, "JSON output")
+			output.Bool("yaml", false, "YAML output")
+			output.Bool("text", false, "Text output")
+			logging := cmd.NewFlagSet("Logging")
+			logging.Bool("verbose", false, "Verbose output")
+			logging.Bool("quiet", false, "Quiet output")
+
+			for _, group := range [][]string{{"json", "yaml", "text"}, {"verbose", "quiet"}, {"text", "quiet"}} {
+				if err := cmd.MarkFlagsMutuallyExclusive(group...); err != nil {
+					t.Fatalf("MarkFlagsMutuallyExclusive() error = %v", err)
+				}
+			}
+
+			err := cmd.ParseSilent(tt.args)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("ParseSilent() error = %v, want nil", err)
+			case tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr):
+				t.Errorf("ParseSilent() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMarkFlagsMutuallyExclusiveUnknownFlag(t *testing.T) {
+	cmd := New()
+	cmd.NewFlagSet("Output").Bool("json", false, "JSON output")
+
+	if err := cmd.MarkFlagsMutuallyExclusive("json", "yaml"); err == nil {
+		t.Error("MarkFlagsMutuallyExclusive() error = nil, want an error for an unknown flag")
+	}
+}
+
+func TestMarkFlagsRequiredTogether(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "none set"},
+		{name: "all set", args: []string{"--db-user", "admin", "--db-password", "secret"}},
+		{
+			name:    "one missing",
+			args:    []string{"--db-password", "secret"},
+			wantErr: `flag(s) "--db-user/-u" must be set when "--db-password" is set`,
+		},
+		{
+			name:    "across groups",
+			args:    []string{"--tls"},
+			wantErr: `flag(s) "--cert" must be set when "--tls" is set`,
+		},
+		{
+			name:    "with an exclusive group",
+			args:    []string{"--db-user", "admin", "--db-password", "secret", "--json", "--yaml"},
+			wantErr: `flags "--json", "--yaml" are mutually exclusive`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := New()
+			db := cmd.NewFlagSet("Database")
+			db.StringP("db-user", "u", "", "Database user")
+			db.String("db-password", "", "Database password")
+			db.Bool("tls", false, "Use TLS")
+			output := cmd.NewFlagSet("Output")
+			output.Bool("json", false, "JSON output")
+			output.Bool("yaml", false, "YAML output")
+			cmd.NewFlagSet("Security").String("cert", "", "Certificate file")
+
+			if err := cmd.MarkFlagsRequiredTogether("db-user", "db-password"); err != nil {
+				t.Fatalf("MarkFlagsRequiredTogether() error = %v", err)
+			}
+			if err := cmd.MarkFlagsRequiredTogether("tls", "cert"); err != nil {
+				t.Fatalf("MarkFlagsRequiredTogether() error = %v", err)
+			}
+			if err := cmd.MarkFlagsMutuallyExclusive("json", "yaml"); err != nil {
+				t.Fatalf("MarkFlagsMutuallyExclusive() error = %v", err)
+			}
+
+			err := cmd.ParseSilent(tt.args)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("ParseSilent() error = %v, want nil", err)
+			case tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr):
+				t.Errorf("ParseSilent() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	cmd := New()
+	cmd.NewFlagSet("Database").String("db-user", "", "Database user")
+	if err := cmd.MarkFlagsRequiredTogether("db-user", "db-password"); err == nil {
+		t.Error("MarkFlagsRequiredTogether() error = nil, want an error for an unknown flag")
+	}
+}
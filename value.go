@@ -0,0 +1,352 @@
+package pflagx
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"unsafe"
+
+	"github.com/spf13/pflag"
+)
+
+// onSetValue wraps a pflag.Value to call a function each time
+// a value is successfully set.
+type onSetValue struct {
+	pflag.Value
+
+	fn func(value string)
+}
+
+// Set sets the wrapped value and calls the function with the raw value.
+func (v *onSetValue) Set(value string) error {
+	if err := v.Value.Set(value); err != nil {
+		return err
+	}
+
+	v.fn(value)
+	return nil
+}
+
+// validatedValue wraps a pflag.Value to validate each value successfully set.
+type validatedValue struct {
+	pflag.Value
+
+	fn func(value string) error
+}
+
+// Set sets the wrapped value and validates the raw value.
+func (v *validatedValue) Set(value string) error {
+	if err := v.Value.Set(value); err != nil {
+		return err
+	}
+
+	return v.fn(value)
+}
+
+// aliasedValue wraps a pflag.Value shared by a flag and its aliases, so that
+// they can all be marked as changed when any of them is given on the command
+// line. See syncAliases.
+type aliasedValue struct {
+	pflag.Value
+
+	flags []*pflag.Flag
+}
+
+// syncAliases marks every name of the flags given on the command line parsed
+// by fs as changed, so that a flag set through an alias is seen as set under
+// its canonical name. Values set from the environment or a config file do not
+// mark any flag as changed.
+func syncAliases(fs *pflag.FlagSet) {
+	fs.Visit(func(f *pflag.Flag) {
+		if v, ok := f.Value.(*aliasedValue); ok {
+			for _, alias := range v.flags {
+				alias.Changed = true
+			}
+		}
+	})
+}
+
+// wrapValue replaces the value of f with the one returned by wrap. The value
+// shared by a flag and its aliases is wrapped behind the aliasedValue, so that
+// the wrapper applies to every name of the flag.
+func wrapValue(f *pflag.Flag, wrap func(pflag.Value) pflag.Value) {
+	if v, ok := f.Value.(*aliasedValue); ok {
+		v.Value = wrap(v.Value)
+		return
+	}
+	f.Value = wrap(f.Value)
+}
+
+// baseValue returns the value wrapped by the wrappers of this package, such
+// as the one installed by OnSet, or v itself if it is not wrapped.
+func baseValue(v pflag.Value) pflag.Value {
+	for {
+		switch w := v.(type) {
+		case *onSetValue:
+			v = w.Value
+		case *validatedValue:
+			v = w.Value
+		case *aliasedValue:
+			v = w.Value
+		default:
+			return v
+		}
+	}
+}
+
+// setSlice replaces the elements of the slice value behind the wrappers of
+// this package, then runs the callbacks and validators of the wrappers as if
+// raw had been set. It reports false, without setting anything, if there is
+// no slice value behind v.
+func setSlice(v pflag.Value, elems []string, raw string) (bool, error) {
+	switch w := v.(type) {
+	case *onSetValue:
+		ok, err := setSlice(w.Value, elems, raw)
+		if ok && err == nil {
+			w.fn(raw)
+		}
+		return ok, err
+	case *validatedValue:
+		ok, err := setSlice(w.Value, elems, raw)
+		if ok && err == nil {
+			err = w.fn(raw)
+		}
+		return ok, err
+	case *aliasedValue:
+		return setSlice(w.Value, elems, raw)
+	case pflag.SliceValue:
+		return true, w.Replace(elems)
+	default:
+		return false, nil
+	}
+}
+
+// defaultSetter is implemented by the values of this package whose Set
+// validates the value, to restore their default without validating it.
+type defaultSetter interface {
+	setDefault(def string)
+}
+
+// resetFlag restores the default value of f and clears its changed state.
+// The values of this package are restored without validation, and slice
+// and map values are restored so that they are replaced, rather than added
+// to, when set again. Other defaults that cannot be parsed again leave the
+// value unchanged.
+func resetFlag(f *pflag.Flag) {
+	switch v := baseValue(f.Value).(type) {
+	case defaultSetter:
+		v.setDefault(f.DefValue)
+	case pflag.SliceValue:
+		v.Replace(sliceElems(f.DefValue))
+		clearPflagChanged(v)
+	default:
+		if !resetPflagMap(v, f.DefValue) {
+			v.Set(f.DefValue)
+		}
+	}
+
+	f.Changed = false
+}
+
+// clearPflagChanged clears the unexported changed field of a pflag slice or
+// map value, which makes its next Set add to the current elements instead
+// of replacing them. Values of other packages are left alone.
+func clearPflagChanged(v any) {
+	if field, ok := pflagField(v, "changed"); ok && field.Kind() == reflect.Bool {
+		field.SetBool(false)
+	}
+}
+
+// resetPflagMap restores a pflag map value, such as the one of
+// StringToString, to its default, e.g. "[a=1,b=2]". It reports false if v
+// is not a pflag map value.
+func resetPflagMap(v pflag.Value, def string) bool {
+	field, ok := pflagField(v, "value")
+	if !ok || field.Kind() != reflect.Pointer || field.IsNil() || field.Elem().Kind() != reflect.Map {
+		return false
+	}
+
+	field.Elem().SetZero()
+	clearPflagChanged(v)
+	if def = strings.Trim(def, "[]"); def != "" {
+		v.Set(def)
+		clearPflagChanged(v)
+	}
+	return true
+}
+
+// pflagField returns the settable unexported field of the value if it is
+// one of the values defined by pflag.
+func pflagField(v any, name string) (reflect.Value, bool) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct ||
+		rv.Elem().Type().PkgPath() != reflect.TypeFor[pflag.Flag]().PkgPath() {
+		return reflect.Value{}, false
+	}
+
+	field := rv.Elem().FieldByName(name)
+	if !field.IsValid() {
+		return reflect.Value{}, false
+	}
+	return reflect.NewAt(field.Type(), unsafe.Pointer(field.UnsafeAddr())).Elem(), true
+}
+
+// sliceElems returns the elements of the string form of a slice value, such
+// as `[a,"b,c"]`, which pflag writes as CSV between brackets.
+func sliceElems(s string) []string {
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "["), "]")
+	if s == "" {
+		return nil
+	}
+
+	elems, err := csv.NewReader(strings.NewReader(s)).Read()
+	if err != nil {
+		return strings.Split(s, ",")
+	}
+	return elems
+}
+
+// intBaseValue is an int value that accepts the 0x, 0o and 0b prefixes
+// in addition to decimal numbers.
+type intBaseValue int
+
+func newIntBaseValue(val int, p *int) *intBaseValue {
+	*p = val
+	return (*intBaseValue)(p)
+}
+
+// Set parses the value, detecting the base from its prefix.
+func (i *intBaseValue) Set(s string) error {
+	v, err := strconv.ParseInt(s, 0, strconv.IntSize)
+	if err != nil {
+		return err
+	}
+
+	*i = intBaseValue(v)
+	return nil
+}
+
+// Type returns the type of the value.
+func (i *intBaseValue) Type() string {
+	return "int"
+}
+
+// String returns the value as a decimal number.
+func (i *intBaseValue) String() string {
+	return strconv.Itoa(int(*i))
+}
+
+// ratioValue is a float64 value optionally bounded to an inclusive range.
+type ratioValue struct {
+	value *float64
+
+	// min and max bound the value when bounded is true.
+	min, max float64
+	bounded  bool
+
+	// decimals is the number of decimals used to format the value,
+	// or -1 for the smallest number necessary.
+	decimals int
+}
+
+func newRatioValue(val float64, p *float64) *ratioValue {
+	*p = val
+	return &ratioValue{value: p, decimals: -1}
+}
+
+// Set parses the value and validates that it is within the range.
+func (r *ratioValue) Set(s string) error {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return err
+	}
+
+	if math.IsNaN(v) {
+		return fmt.Errorf("value is not a number")
+	}
+
+	if r.bounded && (v < r.min || v > r.max) {
+		return fmt.Errorf("value must be between %s and %s", r.format(r.min), r.format(r.max))
+	}
+
+	*r.value = v
+	return nil
+}
+
+// Type returns the type of the value.
+func (r *ratioValue) Type() string {
+	return "float64"
+}
+
+// String returns the value formatted with the configured number of decimals.
+func (r *ratioValue) String() string {
+	return r.format(*r.value)
+}
+
+// setDefault sets the value without checking the range.
+func (r *ratioValue) setDefault(def string) {
+	if v, err := strconv.ParseFloat(def, 64); err == nil {
+		*r.value = v
+	}
+}
+
+func (r *ratioValue) format(v float64) string {
+	return strconv.FormatFloat(v, 'f', r.decimals, 64)
+}
+
+// pathValue is a string value holding the path to an existing regular file,
+// or to an existing directory if dir is true. An empty path means unset and
+// is not checked.
+type pathValue struct {
+	value *string
+	dir   bool
+}
+
+func newPathValue(val string, p *string, dir bool) *pathValue {
+	*p = val
+	return &pathValue{value: p, dir: dir}
+}
+
+// Set checks that the path exists and is of the expected kind.
+func (v *pathValue) Set(s string) error {
+	if s != "" {
+		info, err := os.Stat(s)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			return fmt.Errorf("%s does not exist", s)
+		case err != nil:
+			return err
+		case v.dir && !info.IsDir():
+			return fmt.Errorf("%s is not a directory", s)
+		case !v.dir && !info.Mode().IsRegular():
+			return fmt.Errorf("%s is not a regular file", s)
+		}
+	}
+
+	*v.value = s
+	return nil
+}
+
+// setDefault sets the path without checking that it exists.
+func (v *pathValue) setDefault(def string) {
+	*v.value = def
+}
+
+// Type returns "dir" for directories and "file" for files.
+func (v *pathValue) Type() string {
+	if v.dir {
+		return "dir"
+	}
+	return "file"
+}
+
+// String returns the path.
+func (v *pathValue) String() string {
+	return *v.value
+}
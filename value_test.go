@@ -0,0 +1,172 @@
+package pflagx
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIntBase(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{name: "hexadecimal", value: "0xFF", want: 255},
+		{name: "octal", value: "0o755", want: 0o755},
+		{name: "legacy octal", value: "0755", want: 0o755},
+		{name: "binary", value: "0b1010", want: 10},
+		{name: "decimal", value: "42", want: 42},
+		{name: "negative", value: "-0x10", want: -16},
+		{name: "underscores", value: "1_000", want: 1000},
+		{name: "invalid", value: "0xZZ", wantErr: true},
+		{name: "not a number", value: "ten", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := New()
+			mask := cmd.NewFlagSet("General").IntBase("mask", "m", 7, "Permission mask")
+
+			err := cmd.ParseSilent([]string{"--mask", tt.value})
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseSilent() error = nil, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseSilent() error = %v", err)
+			}
+			if *mask != tt.want {
+				t.Errorf("mask = %d, want %d", *mask, tt.want)
+			}
+		})
+	}
+}
+
+func TestRatio(t *testing.T) {
+	tests := []struct {
+		name    string
+		bounded bool
+		value   string
+		want    float64
+		wantErr bool
+	}{
+		{name: "in range", bounded: true, value: "0.8", want: 0.8},
+		{name: "lower bound", bounded: true, value: "0", want: 0},
+		{name: "upper bound", bounded: true, value: "1", want: 1},
+		{name: "below range", bounded: true, value: "-0.1", wantErr: true},
+		{name: "above range", bounded: true, value: "1.01", wantErr: true},
+		{name: "not a number", bounded: true, value: "NaN", wantErr: true},
+		{name: "unbounded", value: "1.5", want: 1.5},
+		{name: "unbounded negative", value: "-3", want: -3},
+		{name: "invalid", value: "half", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := New()
+			fs := cmd.NewFlagSet("General")
+			var threshold *float64
+			if tt.bounded {
+				threshold = fs.RatioRange("threshold", 0.5, 0, 1, "Match threshold")
+			} else {
+				threshold = fs.Ratio("threshold", 0.5, "Match threshold")
+			}
+
+			err := cmd.ParseSilent([]string{"--threshold", tt.value})
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseSilent() error = nil, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseSilent() error = %v", err)
+			}
+			if *threshold != tt.want {
+				t.Errorf("threshold = %v, want %v", *threshold, tt.want)
+			}
+		})
+	}
+}
+
+func TestRatioDecimals(t *testing.T) {
+	tests := []struct {
+		name     string
+		decimals int
+		want     string
+	}{
+		{name: "shortest", decimals: -1, want: "(default: 1.5)"},
+		{name: "none", decimals: 0, want: "(default: 2)"},
+		{name: "two", decimals: 2, want: "(default: 1.50)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := New().NewFlagSet("General")
+			fs.Ratio("factor", 1.5, "Scale factor")
+			if err := fs.SetDecimals("factor", tt.decimals); err != nil {
+				t.Fatalf("SetDecimals() error = %v", err)
+			}
+
+			if got := fs.defaultString(fs.Lookup("factor")); got != tt.want {
+				t.Errorf("defaultString() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExistingPath(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, "missing")
+
+	tests := []struct {
+		name    string
+		flag    string
+		value   string
+		wantErr bool
+	}{
+		{name: "file", flag: "config", value: file},
+		{name: "directory as file", flag: "config", value: dir, wantErr: true},
+		{name: "missing file", flag: "config", value: missing, wantErr: true},
+		{name: "empty file", flag: "config", value: ""},
+		{name: "directory", flag: "workdir", value: dir},
+		{name: "file as directory", flag: "workdir", value: file, wantErr: true},
+		{name: "missing directory", flag: "workdir", value: missing, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := New()
+			fs := cmd.NewFlagSet("General")
+			paths := map[string]*string{
+				"config":  fs.ExistingFileP("config", "c", missing, "Config file"),
+				"workdir": fs.ExistingDir("workdir", "", "Working directory"),
+			}
+
+			err := cmd.ParseSilent([]string{"--" + tt.flag, tt.value})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSilent() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && *paths[tt.flag] != tt.value {
+				t.Errorf("%s = %q, want %q", tt.flag, *paths[tt.flag], tt.value)
+			}
+		})
+	}
+
+	fs := New().NewFlagSet("General")
+	fs.ExistingFile("config", "", "Config file")
+	fs.ExistingDirP("workdir", "w", "", "Working directory")
+	for name, want := range map[string]string{"config": "file", "workdir": "dir"} {
+		if got := fs.Lookup(name).Value.Type(); got != want {
+			t.Errorf("Type() of --%s = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package pflagx
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// minWrapWidth is the minimum number of columns that must be available to
+// the lines of a text for it to be wrapped. Narrower texts are written as is.
+const minWrapWidth = 20
+
+// wrapLine splits s at word boundaries into lines that fit in width columns,
+// the first line starting at column first and the following ones at column
+// rest. Words kept on the same line are separated by their original spacing,
+// which preserves the alignment of columns within the text. The tail, which
+// includes its own leading separator, is appended to the last word and never
+// separated from it. Words that are too long to fit are placed on their own
+// line. If width is zero, too narrow, or s already fits, s is returned as is,
+// followed by the tail.
+func wrapLine(s, tail string, width, first, rest int) []string {
+	full := s + tail
+	if s == "" {
+		full = strings.TrimLeft(tail, " ")
+	}
+
+	if width-max(first, rest) < minWrapWidth || first+visibleLength(full) <= width {
+		return []string{full}
+	}
+
+	words := splitWords(s)
+	if len(words) > 0 {
+		words[len(words)-1].text += tail
+	} else if full != "" {
+		words = []word{{text: full}}
+	}
+
+	var lines []string
+	line := strings.Builder{}
+	column := first
+
+	for _, w := range words {
+		wordLen := visibleLength(w.text)
+
+		if line.Len() > 0 {
+			gapLen := visibleLength(w.gap)
+			if column+gapLen+wordLen <= width {
+				line.WriteString(w.gap)
+				line.WriteString(w.text)
+				column += gapLen + wordLen
+				continue
+			}
+
+			lines = append(lines, line.String())
+			line.Reset()
+			column = rest
+		}
+
+		line.WriteString(w.text)
+		column += wordLen
+	}
+
+	return append(lines, line.String())
+}
+
+// word is a word of a text along with the whitespace preceding it.
+type word struct {
+	gap  string
+	text string
+}
+
+// splitWords splits s into words separated by whitespace, keeping the
+// whitespace between them. The whitespace before the first word and after
+// the last one is dropped.
+func splitWords(s string) []word {
+	var words []word
+	for s != "" {
+		start := strings.IndexFunc(s, func(r rune) bool { return !unicode.IsSpace(r) })
+		if start < 0 {
+			break
+		}
+
+		end := strings.IndexFunc(s[start:], unicode.IsSpace)
+		if end < 0 {
+			end = len(s) - start
+		}
+
+		w := word{gap: s[:start], text: s[start : start+end]}
+		if len(words) == 0 {
+			w.gap = ""
+		}
+		words = append(words, w)
+		s = s[start+end:]
+	}
+	return words
+}
+
+// visibleLength returns the number of characters of s shown on a terminal,
+// ignoring ANSI escape sequences.
+func visibleLength(s string) int {
+	n := 0
+	for i := 0; i < len(s); {
+		// Skip CSI sequences such as "\x1b[36m"
+		if s[i] == '\x1b' && i+1 < len(s) && s[i+1] == '[' {
+			i += 2
+			for i < len(s) && (s[i] < 0x40 || s[i] > 0x7e) {
+				i++
+			}
+			i++
+			continue
+		}
+
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		n++
+	}
+	return n
+}
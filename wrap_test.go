@@ -0,0 +1,208 @@
+package pflagx
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestWrapLine(t *testing.T) {
+	tests := []struct {
+		name        string
+		s, tail     string
+		width       int
+		first, rest int
+		want        []string
+	}{
+		{
+			name:  "fits",
+			s:     "Verbose output",
+			tail:  " (default: true)",
+			width: 40,
+			want:  []string{"Verbose output (default: true)"},
+		},
+		{
+			name:  "word boundaries",
+			s:     "Write the generated report to the given file",
+			width: 30,
+			first: 10,
+			rest:  10,
+			want:  []string{"Write the generated", "report to the given", "file"},
+		},
+		{
+			name:  "tail kept with the last word",
+			s:     "Write the generated report to disk",
+			tail:  " (default: 1)",
+			width: 30,
+			first: 6,
+			rest:  6,
+			want:  []string{"Write the generated", "report to", "disk (default: 1)"},
+		},
+		{
+			name:  "tail without text",
+			tail:  " (default: 1)",
+			width: 30,
+			want:  []string{"(default: 1)"},
+		},
+		{
+			name:  "long word on its own line",
+			s:     "See https://example.com/a/very/long/path for details",
+			width: 30,
+			want:  []string{"See", "https://example.com/a/very/long/path", "for details"},
+		},
+		{
+			name:  "inner spacing kept",
+			s:     "one  two  three  four  five  six  seven",
+			width: 24,
+			want:  []string{"one  two  three  four", "five  six  seven"},
+		},
+		{
+			name:  "too narrow",
+			s:     "Write the generated report to the given file",
+			width: 30,
+			first: 18,
+			rest:  18,
+			want:  []string{"Write the generated report to the given file"},
+		},
+		{
+			name: "no width",
+			s:    "Write the generated report to the given file",
+			want: []string{"Write the generated report to the given file"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := wrapLine(tt.s, tt.tail, tt.width, tt.first, tt.rest)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("wrapLine() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWrapUsage(t *testing.T) {
+	cmd := New()
+	cmd.Width = 40
+	fs := cmd.NewFlagSet("General")
+	fs.String("output", "out", "Write the generated report to the given file path")
+	fs.Bool("quiet", false, "Suppress output.\nErrors are still written to the standard error")
+
+	want := "General:\n" +
+		"      --output    Write the generated\n" +
+		"                  report to the given\n" +
+		"                  file\n" +
+		"                  path (default: \"out\")\n" +
+		"      --quiet     Suppress output.\n" +
+		"                  Errors are still\n" +
+		"                  written to the\n" +
+		"                  standard error\n"
+
+	got, err := cmd.RenderFlagSet("General")
+	if err != nil {
+		t.Fatalf("RenderFlagSet() error = %v", err)
+	}
+	if got != want {
+		t.Errorf("RenderFlagSet() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestContinuationIndent(t *testing.T) {
+	tests := []struct {
+		name   string
+		indent int
+		want   string
+	}{
+		{
+			name: "none",
+			want: "General:\n" +
+				"      --output    Write the generated\n" +
+				"                  report to the file\n" +
+				"      --quiet     Suppress output.\n" +
+				"                  Errors are kept.\n",
+		},
+		{
+			name:   "negative",
+			indent: -2,
+			want: "General:\n" +
+				"      --output    Write the generated\n" +
+				"                report to the file\n" +
+				"      --quiet     Suppress output.\n" +
+				"                Errors are kept.\n",
+		},
+		{
+			name:   "positive",
+			indent: 2,
+			want: "General:\n" +
+				"      --output    Write the generated\n" +
+				"                    report to the file\n" +
+				"      --quiet     Suppress output.\n" +
+				"                    Errors are kept.\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := New()
+			cmd.Width = 40
+			cmd.ContinuationIndent = tt.indent
+			fs := cmd.NewFlagSet("General")
+			fs.String("output", "", "Write the generated report to the file")
+			fs.Bool("quiet", false, "Suppress output.\nErrors are kept.")
+
+			got, err := cmd.RenderFlagSet("General")
+			if err != nil {
+				t.Fatalf("RenderFlagSet() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("RenderFlagSet() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWrapDescriptionAndFooter(t *testing.T) {
+	tests := []struct {
+		name         string
+		preformatted bool
+		want         string
+	}{
+		{
+			name: "wrapped",
+			want: "General:\n" +
+				"  Options shared by every subcommand of\n" +
+				"  the application\n" +
+				"      --quiet    Suppress output\n" +
+				"  See the manual for the full list of\n" +
+				"  options\n",
+		},
+		{
+			name:         "preformatted footer",
+			preformatted: true,
+			want: "General:\n" +
+				"  Options shared by every subcommand of\n" +
+				"  the application\n" +
+				"      --quiet    Suppress output\n" +
+				"  See the manual for the full list of options\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := New()
+			cmd.Width = 40
+			fs := cmd.NewFlagSet("General")
+			fs.Description = "Options shared by every subcommand of the application"
+			fs.Footer = "See the manual for the full list of options"
+			fs.FooterPreformatted = tt.preformatted
+			fs.Bool("quiet", false, "Suppress output")
+
+			got, err := cmd.RenderFlagSet("General")
+			if err != nil {
+				t.Fatalf("RenderFlagSet() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("RenderFlagSet() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}